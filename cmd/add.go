@@ -3,27 +3,119 @@ package cmd
 import (
 	"R2-D2/todo"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var secretFlag bool
+var dueFlag string
+var recurFlag string
+var tagFlags []string
+var parentFlag int
+var estimateFlag string
+var addSetFlags []string
+var waitFlag string
+var scheduledFlag string
+var priorityFlag string
 
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new task",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Join all arguments to form the complete task description
-		description := strings.Join(args, " ")
+		// Join all arguments to form the complete task description,
+		// pulling out "+tag" words along the way
+		description, tags := todo.ExtractTags(args)
+		for _, tag := range tagFlags {
+			normalized, ok := todo.NormalizeTag(tag)
+			if !ok {
+				fmt.Printf("Invalid tag %q\n", tag)
+				return
+			}
+			tags = todo.AddTags(tags, normalized)
+		}
+		if description == "" {
+			fmt.Println("Task description cannot be empty")
+			return
+		}
 
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
 		tasks, err := todo.LoadTasks("tasks.csv")
 		if err != nil {
 			fmt.Println("Error loading tasks:", err)
 			return
 		}
+		before := todo.CloneTasks(tasks)
+
+		if parentFlag != 0 && !taskExists(tasks, parentFlag) {
+			fmt.Printf("Parent task %d not found\n", parentFlag)
+			return
+		}
+
+		due := time.Time{}
+		if dueFlag != "" {
+			due, err = todo.ParseDate(dueFlag, time.Now)
+			if err != nil {
+				fmt.Println("Error parsing due date:", err)
+				return
+			}
+		}
+
+		wait, scheduled := time.Time{}, time.Time{}
+		if waitFlag != "" {
+			wait, err = todo.ParseDate(waitFlag, time.Now)
+			if err != nil {
+				fmt.Println("Error parsing wait date:", err)
+				return
+			}
+		}
+		if scheduledFlag != "" {
+			scheduled, err = todo.ParseDate(scheduledFlag, time.Now)
+			if err != nil {
+				fmt.Println("Error parsing scheduled date:", err)
+				return
+			}
+		}
+
+		priority, err := todo.ParsePriority(priorityFlag)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		estimate := time.Duration(0)
+		if estimateFlag != "" {
+			estimate, err = time.ParseDuration(estimateFlag)
+			if err != nil || estimate < 0 {
+				fmt.Printf("Invalid estimate %q: use a duration like 45m or 2h30m\n", estimateFlag)
+				return
+			}
+		}
+
+		recur := ""
+		if recurFlag != "" {
+			rule, err := todo.ParseRecurrence(recurFlag)
+			if err != nil {
+				fmt.Println("Error parsing recurrence:", err)
+				return
+			}
+			if due.IsZero() {
+				// Start the series on its first occurrence from today on
+				now := time.Now()
+				first, ok := rule.Anchor(now).Next(todo.EndOfDay(now).AddDate(0, 0, -1))
+				if !ok {
+					fmt.Println("Error parsing recurrence: no occurrences left")
+					return
+				}
+				due = first
+			}
+			recur = rule.Anchor(due).String()
+		}
 
 		// Handle encryption if --secret flag is provided
 		encrypted := false
@@ -38,15 +130,27 @@ var addCmd = &cobra.Command{
 		}
 
 		task := todo.Task{
-			ID:          len(tasks) + 1,
+			ID:          todo.NextID(tasks),
 			Description: description,
 			Completed:   false,
 			CreatedAt:   time.Now(),
 			CompletedAt: time.Time{},
 			Encrypted:   encrypted,
+			Due:         due,
+			Recur:       recur,
+			Tags:        tags,
+			ParentID:    parentFlag,
+			Estimate:    estimate,
+			Wait:        wait,
+			Scheduled:   scheduled,
+			Priority:    priority,
+		}
+		if err := setTaskFields(&task, cfg, addSetFlags); err != nil {
+			fmt.Println("Error:", err)
+			return
 		}
 		tasks = append(tasks, task)
-		err = todo.SaveTasks("tasks.csv", tasks)
+		err = saveTasks("add", before, tasks)
 		if err != nil {
 			fmt.Println("Error saving tasks:", err)
 			return
@@ -60,7 +164,26 @@ var addCmd = &cobra.Command{
 	},
 }
 
+// taskExists reports whether a task with the given ID is in the list.
+func taskExists(tasks []todo.Task, id int) bool {
+	for _, task := range tasks {
+		if task.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	rootCmd.AddCommand(addCmd)
 	addCmd.Flags().BoolVarP(&secretFlag, "secret", "s", false, "Add task as encrypted secret")
+	addCmd.Flags().StringVar(&dueFlag, "due", "", "Due date (e.g. tomorrow, next fri, in 3d, eom, 2025-04-10)")
+	addCmd.Flags().StringSliceVarP(&tagFlags, "tag", "t", nil, "Tag the task (repeatable, or use +tag in the description)")
+	addCmd.Flags().IntVar(&parentFlag, "parent", 0, "Add the task as a subtask of this task ID")
+	addCmd.Flags().StringVar(&waitFlag, "wait", "", "Hide the task from list until this date")
+	addCmd.Flags().StringVar(&scheduledFlag, "scheduled", "", "Date the task can be started")
+	addCmd.Flags().StringVarP(&priorityFlag, "priority", "p", "", "Priority: H, M or L")
+	addCmd.Flags().StringVar(&estimateFlag, "estimate", "", "Expected effort, e.g. 45m or 2h30m")
+	addCmd.Flags().StringArrayVar(&addSetFlags, "set", nil, "Set a custom field (key=value, repeatable)")
+	addCmd.Flags().StringVar(&recurFlag, "recur", "", `Repeat the task (e.g. "every weekday", "every 2 weeks" or an RRULE)`)
 }
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [task ID] [note]",
+	Short: "Add a timestamped note to a task",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error converting task ID to int:", err)
+			return
+		}
+		text := strings.Join(args[1:], " ")
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		before := todo.CloneTasks(tasks)
+		for i, task := range tasks {
+			if task.ID != id {
+				continue
+			}
+			if err := todo.Annotate(&tasks[i], text, time.Now()); err != nil {
+				fmt.Println("Error encrypting note:", err)
+				return
+			}
+			if err := saveTasks("annotate", before, tasks); err != nil {
+				fmt.Println("Error saving tasks:", err)
+				return
+			}
+			fmt.Printf("Task %d annotated\n", id)
+			return
+		}
+		fmt.Printf("Task %d not found\n", id)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+}
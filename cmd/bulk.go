@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var yesFlag bool
+var dryRunFlag bool
+
+// Stdin is the one buffered reader of standard input. The REPL reads its
+// commands from it and the prompts their answers, so neither can buffer a
+// line meant for the other.
+var Stdin = bufio.NewReader(os.Stdin)
+
+// confirmInput is where answers to confirmation prompts are read from.
+var confirmInput = Stdin
+
+// addBulkFlags gives a command that changes many tasks at once its --yes
+// and --dry-run flags.
+func addBulkFlags(c *cobra.Command) {
+	c.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Don't ask before changing many tasks")
+	c.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would change without saving")
+}
+
+// confirmBulk asks before a command touches more tasks than the config's
+// bulk setting allows without asking. --yes and --dry-run skip the prompt.
+func confirmBulk(verb string, ids []int, tasks []todo.Task, cfg todo.Config) bool {
+	if yesFlag || dryRunFlag || cfg.Bulk <= 0 || len(ids) <= cfg.Bulk {
+		return true
+	}
+	fmt.Printf("This will %s %d tasks:\n", verb, len(ids))
+	for _, id := range ids {
+		if i := taskIndex(tasks, id); i >= 0 {
+			fmt.Printf("  %d %s\n", id, displayDescription(tasks[i], false))
+		}
+	}
+	fmt.Print("Continue? [y/N] ")
+	answer := strings.ToLower(strings.TrimSpace(readLine(confirmInput)))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	fmt.Println("Aborted")
+	return false
+}
+
+// readLine reads up to the next newline, leaving the rest for whoever
+// reads r next.
+func readLine(r *bufio.Reader) string {
+	line, _ := r.ReadString('\n')
+	return strings.TrimSuffix(line, "\n")
+}
+
+// reportChanges prints what a bulk command did, or would have done on a
+// dry run, and saves the tasks unless it is one.
+func reportChanges(command string, before, tasks []todo.Task, messages []string) {
+	if dryRunFlag {
+		for _, message := range messages {
+			fmt.Println("[dry run]", message)
+		}
+		fmt.Println("Dry run: nothing was saved")
+		return
+	}
+	if err := saveTasks(command, before, tasks); err != nil {
+		fmt.Println("Error saving tasks:", err)
+		return
+	}
+	for _, message := range messages {
+		fmt.Println(message)
+	}
+}
+
+// saveTasks writes the tasks and records what changed since before in the
+// history, so undo can take it back.
+func saveTasks(command string, before, after []todo.Task) error {
+	if err := todo.SaveTasks("tasks.csv", after); err != nil {
+		return err
+	}
+	if err := todo.RecordHistory("history.csv", command, 0, before, after, time.Now()); err != nil {
+		fmt.Println("Warning: the change was saved but not added to the history:", err)
+	}
+	return nil
+}
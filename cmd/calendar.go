@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var agendaDaysFlag int
+var agendaWeekFlag bool
+
+var calendarCmd = &cobra.Command{
+	Use:   "calendar [YYYY-MM]",
+	Short: "Show a month with the days tasks are due or were completed",
+	Long: `Show a month, this one unless given, marking the days tasks are due (•),
+overdue (!) or were completed (✓), followed by the tasks on those days.
+
+Weeks start on Monday and days follow the system time zone unless
+"week_start" (e.g. "sunday") or "timezone" (e.g. "America/Sao_Paulo") are
+set in config.json.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		now := time.Now().In(cfg.Location())
+		year, month := now.Year(), now.Month()
+		if len(args) == 1 {
+			t, err := time.ParseInLocation("2006-01", args[0], cfg.Location())
+			if err != nil {
+				fmt.Printf("Invalid month %q: use YYYY-MM\n", args[0])
+				return
+			}
+			year, month = t.Year(), t.Month()
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		weeks := todo.Month(tasks, year, month, cfg.Location(), cfg.FirstWeekday())
+		printCalendar(os.Stdout, weeks, now, cfg.FirstWeekday(), stdoutIsTerminal() && os.Getenv("NO_COLOR") == "")
+	},
+}
+
+var agendaCmd = &cobra.Command{
+	Use:   "agenda",
+	Short: "List what is due or scheduled day by day",
+	Long: `List the open tasks due or scheduled on each of the coming days, with
+overdue tasks at the top. --week shows the whole current week instead,
+starting on the config's "week_start".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if agendaDaysFlag < 1 {
+			fmt.Println("--days must be at least 1")
+			return
+		}
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		now := time.Now().In(cfg.Location())
+		from, days := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), agendaDaysFlag
+		if agendaWeekFlag {
+			from, days = todo.StartOfWeekOn(now, cfg.FirstWeekday()), 7
+		}
+		overdue, agenda := todo.Agenda(tasks, from, days, now)
+		printAgenda(os.Stdout, overdue, agenda, now)
+	},
+}
+
+func printCalendar(w io.Writer, weeks [][]todo.CalendarDay, now time.Time, first time.Weekday, color bool) {
+	var month time.Time
+	for _, day := range weeks[0] {
+		if !day.Date.IsZero() {
+			month = day.Date
+			break
+		}
+	}
+	title := month.Format("January 2006")
+	fmt.Fprintf(w, "%*s\n", (28+len(title))/2, title)
+	header := ""
+	for i := 0; i < 7; i++ {
+		header += fmt.Sprintf("%3s ", time.Weekday((int(first) + i) % 7).String()[:2])
+	}
+	fmt.Fprintln(w, strings.TrimRight(header, " "))
+
+	today := now.Format("2006-01-02")
+	marked := []todo.CalendarDay{}
+	for _, week := range weeks {
+		var line strings.Builder
+		for _, day := range week {
+			if day.Date.IsZero() {
+				line.WriteString("    ")
+				continue
+			}
+			number := fmt.Sprintf("%3d", day.Date.Day())
+			if color && day.Date.Format("2006-01-02") == today {
+				number = " \x1b[7m" + strings.TrimSpace(number) + "\x1b[0m"
+				if day.Date.Day() < 10 {
+					number = " " + number
+				}
+			}
+			line.WriteString(number + calendarMark(day, now))
+			if len(day.Due)+len(day.Completed) > 0 {
+				marked = append(marked, day)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+
+	if len(marked) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, day := range marked {
+		date := day.Date.Format("Jan _2")
+		for _, task := range day.Due {
+			mark := "•"
+			if task.Overdue(now) {
+				mark = "!"
+			}
+			fmt.Fprintf(w, "%s  %s %d  %s\n", date, mark, task.ID, displayDescription(task, false))
+			date = strings.Repeat(" ", len(date))
+		}
+		for _, task := range day.Completed {
+			fmt.Fprintf(w, "%s  ✓ %d  %s\n", date, task.ID, displayDescription(task, false))
+			date = strings.Repeat(" ", len(date))
+		}
+	}
+}
+
+// calendarMark is the sign after a day's number: overdue tasks win over
+// tasks due and those over completed ones.
+func calendarMark(day todo.CalendarDay, now time.Time) string {
+	mark := " "
+	if len(day.Completed) > 0 {
+		mark = "✓"
+	}
+	for _, task := range day.Due {
+		if task.Overdue(now) {
+			return "!"
+		}
+		mark = "•"
+	}
+	return mark
+}
+
+func printAgenda(w io.Writer, overdue []todo.Task, agenda []todo.AgendaDay, now time.Time) {
+	if len(overdue) > 0 {
+		fmt.Fprintln(w, "Overdue")
+		for _, task := range overdue {
+			fmt.Fprintf(w, "  %3d  %s  (%s)\n", task.ID, displayDescription(task, false), todo.RelativeDue(task.Due, now))
+		}
+		fmt.Fprintln(w)
+	}
+
+	today := now.Format("2006-01-02")
+	for _, day := range agenda {
+		heading := day.Date.Format("Mon Jan 2")
+		if day.Date.Format("2006-01-02") == today {
+			heading += " (today)"
+		}
+		fmt.Fprintln(w, heading)
+		if len(day.Items) == 0 {
+			fmt.Fprintln(w, "     -")
+		}
+		for _, item := range day.Items {
+			at := ""
+			if local := item.At.In(now.Location()); !local.Equal(todo.EndOfDay(local)) {
+				at = local.Format("15:04")
+			}
+			line := fmt.Sprintf("  %3d  %-5s  %s", item.Task.ID, at, displayDescription(item.Task, false))
+			if item.Task.Priority != "" {
+				line += "  [" + item.Task.Priority + "]"
+			}
+			if item.Scheduled {
+				line += "  (scheduled)"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(calendarCmd, agendaCmd)
+	agendaCmd.Flags().IntVar(&agendaDaysFlag, "days", 7, "Number of days to show, starting today")
+	agendaCmd.Flags().BoolVar(&agendaWeekFlag, "week", false, "Show the current week instead")
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// chartLayer is one series of a chart. Layers stack bottom to top.
+type chartLayer struct {
+	name   string
+	values []float64
+	glyph  string // cell fill in Unicode mode
+	ascii  string // cell fill in ASCII mode
+	color  string // SVG fill
+}
+
+// chart is a bar chart with one column per day.
+type chart struct {
+	title  string
+	days   []time.Time
+	layers []chartLayer
+}
+
+var eighths = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// sample keeps at most width columns, taking the last day of each run so
+// the final column is always today.
+func (c chart) sample(width int) chart {
+	n := len(c.days)
+	if width <= 0 || n <= width {
+		return c
+	}
+	sampled := chart{title: c.title}
+	picks := []int{}
+	for i := 0; i < width; i++ {
+		picks = append(picks, (i+1)*n/width-1)
+	}
+	for _, p := range picks {
+		sampled.days = append(sampled.days, c.days[p])
+	}
+	for _, layer := range c.layers {
+		values := []float64{}
+		for _, p := range picks {
+			values = append(values, layer.values[p])
+		}
+		layer.values = values
+		sampled.layers = append(sampled.layers, layer)
+	}
+	return sampled
+}
+
+func (c chart) max() float64 {
+	top := 1.0
+	for i := range c.days {
+		total := 0.0
+		for _, layer := range c.layers {
+			total += layer.values[i]
+		}
+		top = math.Max(top, total)
+	}
+	return top
+}
+
+// render draws the chart height rows tall. A single layer gets eighth-cell
+// precision from the Unicode block characters; stacked layers fill whole
+// cells. ASCII mode uses plain characters throughout.
+func (c chart) render(w io.Writer, height int, ascii bool) {
+	top := c.max()
+	scale := float64(height) / top
+	label := func(v float64) string { return fmt.Sprintf("%g", math.Round(v)) }
+	margin := len(label(top))
+
+	glyphs := []string{}
+	for _, layer := range c.layers {
+		if ascii {
+			glyphs = append(glyphs, layer.ascii)
+		} else {
+			glyphs = append(glyphs, layer.glyph)
+		}
+	}
+	tick, axisLine := "┤", "└"+strings.Repeat("─", len(c.days))
+	if ascii {
+		tick, axisLine = "|", "+"+strings.Repeat("-", len(c.days))
+	}
+
+	fmt.Fprintln(w, c.title)
+	for row := height - 1; row >= 0; row-- {
+		axis := ""
+		switch row {
+		case height - 1:
+			axis = label(top)
+		case 0:
+			axis = "0"
+		}
+		var line strings.Builder
+		for i := range c.days {
+			line.WriteString(c.cell(i, row, scale, glyphs, ascii))
+		}
+		fmt.Fprintf(w, "%*s %s%s\n", margin, axis, tick, strings.TrimRight(line.String(), " "))
+	}
+
+	fmt.Fprintf(w, "%*s %s\n", margin, "", axisLine)
+	first, last := c.days[0].Format("Jan 2"), c.days[len(c.days)-1].Format("Jan 2")
+	gap := max(1, len(c.days)+1-len(first)-len(last))
+	fmt.Fprintf(w, "%*s %s%s%s\n", margin, "", first, strings.Repeat(" ", gap), last)
+
+	if len(c.layers) > 1 {
+		legend := []string{}
+		for i := len(c.layers) - 1; i >= 0; i-- {
+			legend = append(legend, glyphs[i]+" "+c.layers[i].name)
+		}
+		fmt.Fprintf(w, "%*s %s\n", margin, "", strings.Join(legend, "  "))
+	}
+}
+
+// cell picks what to draw in column i, row rows up from the axis.
+func (c chart) cell(i, row int, scale float64, glyphs []string, ascii bool) string {
+	if len(c.layers) == 1 {
+		fill := c.layers[0].values[i]*scale - float64(row)
+		switch {
+		case fill >= 1:
+			return glyphs[0]
+		case fill <= 0:
+			return " "
+		case ascii:
+			if fill >= 0.5 {
+				return glyphs[0]
+			}
+			return " "
+		default:
+			return eighths[int(math.Round(fill*8))]
+		}
+	}
+	centre := float64(row) + 0.5
+	bottom := 0.0
+	for j, layer := range c.layers {
+		height := layer.values[i] * scale
+		if centre < bottom+height {
+			return glyphs[j]
+		}
+		bottom += height
+	}
+	return " "
+}
+
+// svg draws the chart as stacked areas, one point per day.
+func (c chart) svg(w io.Writer) {
+	const width, height, left, right, topPad, bottomPad = 640.0, 320.0, 48.0, 16.0, 32.0, 40.0
+	plotW, plotH := width-left-right, height-topPad-bottomPad
+	top := c.max()
+	x := func(i int) float64 {
+		if len(c.days) == 1 {
+			return left + plotW/2
+		}
+		return left + plotW*float64(i)/float64(len(c.days)-1)
+	}
+	y := func(v float64) float64 { return topPad + plotH*(1-v/top) }
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(w, `<rect width="%g" height="%g" fill="white"/>`+"\n", width, height)
+	fmt.Fprintf(w, `<text x="%g" y="20" font-size="14">%s</text>`+"\n", left, c.title)
+
+	base := make([]float64, len(c.days))
+	for _, layer := range c.layers {
+		points := []string{}
+		for i := range c.days {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(base[i]+layer.values[i])))
+		}
+		for i := len(c.days) - 1; i >= 0; i-- {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(base[i])))
+			base[i] += layer.values[i]
+		}
+		fmt.Fprintf(w, `<polygon points="%s" fill="%s" fill-opacity="0.8"><title>%s</title></polygon>`+"\n", strings.Join(points, " "), layer.color, layer.name)
+	}
+
+	fmt.Fprintf(w, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`+"\n", left, topPad, left, topPad+plotH)
+	fmt.Fprintf(w, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`+"\n", left, topPad+plotH, left+plotW, topPad+plotH)
+	fmt.Fprintf(w, `<text x="%g" y="%g" text-anchor="end">%g</text>`+"\n", left-6, topPad+4, math.Round(top))
+	fmt.Fprintf(w, `<text x="%g" y="%g" text-anchor="end">0</text>`+"\n", left-6, topPad+plotH+4)
+	fmt.Fprintf(w, `<text x="%g" y="%g">%s</text>`+"\n", left, height-bottomPad+18, c.days[0].Format("Jan 2"))
+	fmt.Fprintf(w, `<text x="%g" y="%g" text-anchor="end">%s</text>`+"\n", left+plotW, height-bottomPad+18, c.days[len(c.days)-1].Format("Jan 2"))
+	if len(c.layers) > 1 {
+		legendX := left
+		for i := len(c.layers) - 1; i >= 0; i-- {
+			fmt.Fprintf(w, `<rect x="%g" y="%g" width="10" height="10" fill="%s"/>`+"\n", legendX, height-14, c.layers[i].color)
+			fmt.Fprintf(w, `<text x="%g" y="%g">%s</text>`+"\n", legendX+14, height-5, c.layers[i].name)
+			legendX += 100
+		}
+	}
+	fmt.Fprintln(w, "</svg>")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"R2-D2/todo"
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -325,6 +326,9 @@ func TestDeleteCommand(t *testing.T) {
 }
 
 func TestAddSecretTask(t *testing.T) {
+	// add records its change in history.csv next to tasks.csv
+	inTempStore(t)
+
 	// Setup a temporary file for testing
 	tmpfile, err := os.CreateTemp("", "tasks_test.csv")
 	if err != nil {
@@ -567,3 +571,508 @@ func TestListWithSecrets(t *testing.T) {
 		})
 	}
 }
+
+func TestConfirmBulk(t *testing.T) {
+	tasks := []todo.Task{{ID: 1, Description: "a"}, {ID: 2, Description: "b"}, {ID: 3, Description: "c"}}
+	cfg := todo.Config{Bulk: 2}
+	defer func() { confirmInput = Stdin; yesFlag = false; dryRunFlag = false }()
+
+	testCases := []struct {
+		name   string
+		ids    []int
+		answer string
+		yes    bool
+		dryRun bool
+		want   bool
+	}{
+		{name: "under the limit", ids: []int{1, 2}, want: true},
+		{name: "answered yes", ids: []int{1, 2, 3}, answer: "y\n", want: true},
+		{name: "answered no", ids: []int{1, 2, 3}, answer: "n\n", want: false},
+		{name: "no answer", ids: []int{1, 2, 3}, answer: "", want: false},
+		{name: "--yes", ids: []int{1, 2, 3}, yes: true, want: true},
+		{name: "--dry-run", ids: []int{1, 2, 3}, dryRun: true, want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			confirmInput = bufio.NewReader(strings.NewReader(tc.answer))
+			yesFlag, dryRunFlag = tc.yes, tc.dryRun
+			var got bool
+			captureOutput(func() { got = confirmBulk("delete", tc.ids, tasks, cfg) })
+			if got != tc.want {
+				t.Errorf("confirmBulk = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadLineLeavesTheRest(t *testing.T) {
+	// The REPL reads its next command from the same reader
+	r := bufio.NewReader(strings.NewReader("yes\nlist\n"))
+	if got := readLine(r); got != "yes" {
+		t.Errorf("readLine = %q, want %q", got, "yes")
+	}
+	rest, _ := io.ReadAll(r)
+	if string(rest) != "list\n" {
+		t.Errorf("left %q unread, want %q", rest, "list\n")
+	}
+}
+
+func TestRenderTasks(t *testing.T) {
+	encrypted, err := todo.EncryptText("launch codes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2025, time.April, 2, 10, 30, 0, 0, time.UTC)
+	tasks := []todo.Task{
+		{ID: 1, Description: "Deploy | API", CreatedAt: created, Tags: []string{"work"}, Priority: "H",
+			Due: created.Add(48 * time.Hour), Fields: map[string]string{"points": "3"}},
+		{ID: 2, Description: encrypted, Encrypted: true, CreatedAt: created},
+	}
+	cfg := todo.Config{Fields: []todo.FieldDef{{Name: "points", Type: "number"}}}
+	nodes := []todo.TreeNode{{Task: tasks[0]}, {Task: tasks[1]}}
+	view := taskView{ctx: todo.Context{Tasks: tasks, Config: cfg, Now: created}}
+
+	testCases := []struct {
+		format  string
+		columns string
+		want    string
+	}{
+		{"jsonl", "id,description,tags,due,points", `{"id":1,"description":"Deploy | API","tags":["work"],"due":"2025-04-04T10:30:00Z","points":3}
+{"id":2,"description":"[ENCRYPTED]","tags":[],"due":null,"points":null}
+`},
+		{"json", "id,priority", `[
+  {
+    "id": 1,
+    "priority": "H"
+  },
+  {
+    "id": 2,
+    "priority": null
+  }
+]
+`},
+		{"yaml", "id,tags,secret", `- id: 1
+  tags: ["work"]
+  secret: false
+- id: 2
+  tags: []
+  secret: true
+`},
+		{"csv", "id,description,tags,due", `id,description,tags,due
+1,Deploy | API,work,2025-04-04T10:30:00Z
+2,[ENCRYPTED],,
+`},
+		{"markdown", "id,description,due", `| ID | DESCRIPTION | DUE |
+| --- | --- | --- |
+| 1 | Deploy \| API | in 2 days |
+| 2 | [ENCRYPTED] |  |
+`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			columns, err := resolveColumns(tc.columns, tc.format, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := renderTasks(&buf, tc.format, columns, nodes, view); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tc.want)
+			}
+		})
+	}
+
+	t.Run("revealed", func(t *testing.T) {
+		columns, _ := resolveColumns("description", "csv", cfg)
+		var buf bytes.Buffer
+		renderTasks(&buf, "csv", columns, nodes[1:], taskView{ctx: view.ctx, reveal: true})
+		if !strings.Contains(buf.String(), "launch codes") {
+			t.Errorf("secret not revealed: %q", buf.String())
+		}
+	})
+}
+
+func TestResolveColumns(t *testing.T) {
+	cfg := todo.Config{Fields: []todo.FieldDef{{Name: "points", Type: "number"}}}
+	columns, err := resolveColumns("", "table", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != len(defaultTableColumns)+1 || columns[len(columns)-1].name != "points" {
+		t.Errorf("table defaults should be the standard columns plus custom fields")
+	}
+	columns, _ = resolveColumns("", "json", cfg)
+	if len(columns) != len(builtinColumns)+1 {
+		t.Errorf("json should default to every column, got %d", len(columns))
+	}
+	if _, err := resolveColumns("id,bogus", "table", cfg); err == nil {
+		t.Error("unknown column should be rejected")
+	}
+	if _, err := resolveColumns("", "xml", cfg); err == nil {
+		t.Error("unknown format should be rejected")
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	now := time.Date(2025, time.April, 2, 10, 30, 0, 0, time.UTC)
+	cfg := todo.Config{Templates: map[string]string{"brief": "{{.ID}}: {{.Description}}"}}
+	task := todo.Task{ID: 7, Description: "Write the quarterly report", CreatedAt: now.Add(-3 * time.Hour),
+		Due: now.Add(24 * time.Hour), Tags: []string{"work", "q2"}, Estimate: 90 * time.Minute}
+	data := newTaskData(task, taskView{ctx: todo.Context{Tasks: []todo.Task{task}, Now: now}})
+
+	testCases := []struct {
+		template string
+		want     string
+	}{
+		{"{{.ID | padLeft 3}}|{{.Description | truncate 10}}|", "  7|Write the…|"},
+		{"{{.Status | pad 8}}|", "pending |"},
+		{"{{.Due | relative}} {{.CreatedAt | ago}}", "tomorrow 3h ago"},
+		{`{{date "Jan 2" .Due}}{{date "Jan 2" .Wait}}`, "Apr 3"},
+		{"{{duration .Estimate}} {{join \",\" .Tags}} {{.Priority | default \"-\"}}", "1h30m work,q2 -"},
+		{`{{.Description | truncate 5 | upper}}`, "WRIT…"},
+		{`{{"late" | color "red"}}`, "\x1b[31mlate\x1b[0m"},
+		{"brief", "7: Write the quarterly report"},
+	}
+
+	for _, tc := range testCases {
+		tmpl, err := parseTemplate(tc.template, cfg, now)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q): %v", tc.template, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Fatalf("Execute(%q): %v", tc.template, err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s = %q, want %q", tc.template, buf.String(), tc.want)
+		}
+	}
+
+	if _, err := parseTemplate("missing", cfg, now); err == nil {
+		t.Error("unknown template name should be an error")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2025, time.April, 2, 10, 30, 0, 0, time.UTC)
+	tasks := []todo.Task{
+		{ID: 1, Description: "overdue", CreatedAt: now, Due: now.Add(-48 * time.Hour)},
+		{ID: 2, Description: "today", CreatedAt: now, Due: todo.EndOfDay(now), Priority: "L"},
+		{ID: 3, Description: "blocked", CreatedAt: now, DependsOn: []int{2}},
+		{ID: 4, Description: "done", CreatedAt: now, Completed: true},
+		{ID: 5, Description: "waiting", CreatedAt: now, Wait: now.Add(time.Hour)},
+		{ID: 6, Description: "timed", CreatedAt: now, TimeLog: []todo.Interval{{Start: now.Add(-time.Hour)}}},
+	}
+	view := taskView{ctx: todo.Context{Tasks: tasks, Config: todo.DefaultConfig(), Now: now}}
+	data := summarize(tasks, view)
+
+	if data.Pending != 5 || data.Completed != 1 || data.Overdue != 1 || data.DueToday != 1 ||
+		data.Waiting != 1 || data.Blocked != 1 || data.Ready != 3 {
+		t.Errorf("counts = %+v", data)
+	}
+	// Task 2 outranks the overdue task because task 3 is waiting on it
+	if data.Next == nil || data.Next.ID != 2 {
+		t.Errorf("Next = %+v, want task 2", data.Next)
+	}
+	if data.Active == nil || data.Active.ID != 6 || data.Active.Spent != time.Hour {
+		t.Errorf("Active = %+v, want task 6 with 1h spent", data.Active)
+	}
+	if len(data.Tasks) != 5 {
+		t.Errorf("Tasks has %d entries, want the 5 open ones", len(data.Tasks))
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	terms := map[string]bool{"payment": true, "api": true}
+	got := highlightTerms("Fix Payment API, then pay", terms, false)
+	if want := "Fix *Payment* *API*, then pay"; got != want {
+		t.Errorf("highlightTerms = %q, want %q", got, want)
+	}
+	got = highlightTerms("api", terms, true)
+	if want := "\x1b[1;33mapi\x1b[0m"; got != want {
+		t.Errorf("highlightTerms with colour = %q, want %q", got, want)
+	}
+}
+
+func TestEditText(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i s/milk/bread/")
+
+	got, err := editText("buy milk\n")
+	if err != nil || got != "buy bread\n" {
+		t.Fatalf("editText = %q, %v; want \"buy bread\\n\"", got, err)
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("temporary files left behind: %v", left)
+	}
+}
+
+func TestWithEditErrors(t *testing.T) {
+	text := "id: 1\ndue: someday\n# error: line 9: old\n"
+	got := withEditErrors(text, fmt.Errorf("line 2: due: unrecognized date"))
+	if want := "id: 1\ndue: someday\n# error: line 2: due: unrecognized date\n"; got != want {
+		t.Errorf("withEditErrors = %q, want %q", got, want)
+	}
+}
+
+func TestModifyArgs(t *testing.T) {
+	selection, changes := splitModifyArgs([]string{"3", "5,8-9", "priority:H", "+urgent"})
+	if strings.Join(selection, " ") != "3 5,8-9" || strings.Join(changes, " ") != "priority:H +urgent" {
+		t.Errorf("splitModifyArgs = %q, %q", selection, changes)
+	}
+	selection, changes = splitModifyArgs([]string{"tag:old and status:pending", "-old"})
+	if len(selection) != 1 || strings.Join(changes, " ") != "-old" {
+		t.Errorf("splitModifyArgs with a filter = %q, %q", selection, changes)
+	}
+
+	cfg := todo.Config{Fields: []todo.FieldDef{{Name: "points", Type: "number"}}}
+	m, err := parseChanges([]string{"desc:Fix the login bug", "pri:H", "due:", "points:3", "+Urgent", "-later", "^Stale"}, cfg)
+	if err != nil {
+		t.Fatalf("parseChanges: %v", err)
+	}
+	want := modification{
+		fields:     []fieldAssignment{{"description", "Fix the login bug"}, {"priority", "H"}, {"due", ""}, {"points", "3"}},
+		addTags:    []string{"urgent"},
+		removeTags: []string{"later", "stale"},
+	}
+	if fmt.Sprint(m) != fmt.Sprint(want) {
+		t.Errorf("parseChanges = %+v, want %+v", m, want)
+	}
+	for _, bad := range []string{"status:done", "created:today", "words", "+no way"} {
+		if _, err := parseChanges([]string{bad}, cfg); err == nil {
+			t.Errorf("parseChanges(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestChartRender(t *testing.T) {
+	start := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	c := chart{title: "Open", layers: []chartLayer{{name: "open", values: []float64{4, 3, 1.5, 0}, glyph: "█", ascii: "#"}}}
+	for i := 0; i < 4; i++ {
+		c.days = append(c.days, start.AddDate(0, 0, i))
+	}
+
+	var out bytes.Buffer
+	c.render(&out, 4, false)
+	want := "Open\n" +
+		"4 ┤█\n" +
+		"  ┤██\n" +
+		"  ┤██▄\n" +
+		"0 ┤███\n" +
+		"  └────\n" +
+		"  Apr 1 Apr 4\n"
+	if out.String() != want {
+		t.Errorf("render =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	c.layers = []chartLayer{
+		{name: "completed", values: []float64{0, 1, 2, 2}, glyph: "█", ascii: "#"},
+		{name: "open", values: []float64{2, 2, 1, 2}, glyph: "░", ascii: "."},
+	}
+	out.Reset()
+	c.render(&out, 4, true)
+	want = "Open\n" +
+		"4 |   .\n" +
+		"  | ...\n" +
+		"  |..##\n" +
+		"0 |.###\n" +
+		"  +----\n" +
+		"  Apr 1 Apr 4\n" +
+		"  . open  # completed\n"
+	if out.String() != want {
+		t.Errorf("stacked render =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	if sampled := c.sample(2); len(sampled.days) != 2 || !sampled.days[1].Equal(c.days[3]) || sampled.layers[1].values[1] != 2 {
+		t.Errorf("sample(2) = %+v", sampled)
+	}
+
+	out.Reset()
+	c.svg(&out)
+	if svg := out.String(); strings.Count(svg, "<polygon") != 2 || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("svg =\n%s", svg)
+	}
+}
+
+func TestPrintCalendarAndAgenda(t *testing.T) {
+	now := time.Date(2025, time.April, 2, 10, 30, 0, 0, time.UTC)
+	tasks := []todo.Task{
+		{ID: 1, Description: "Pay rent", Due: todo.EndOfDay(now.AddDate(0, 0, -1))},
+		{ID: 2, Description: "Demo", Due: time.Date(2025, time.April, 3, 15, 0, 0, 0, time.UTC), Priority: "H"},
+		{ID: 3, Description: "Shop", Completed: true, CompletedAt: now},
+	}
+
+	var out bytes.Buffer
+	printCalendar(&out, todo.Month(tasks, 2025, time.April, time.UTC, time.Monday), now, time.Monday, false)
+	want := "         April 2025\n" +
+		" Mo  Tu  We  Th  Fr  Sa  Su\n" +
+		"      1!  2✓  3•  4   5   6\n" +
+		"  7   8   9  10  11  12  13\n" +
+		" 14  15  16  17  18  19  20\n" +
+		" 21  22  23  24  25  26  27\n" +
+		" 28  29  30\n" +
+		"\n" +
+		"Apr  1  ! 1  Pay rent\n" +
+		"Apr  2  ✓ 3  Shop\n" +
+		"Apr  3  • 2  Demo\n"
+	if out.String() != want {
+		t.Errorf("calendar =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	overdue, agenda := todo.Agenda(tasks, time.Date(2025, time.April, 2, 0, 0, 0, 0, time.UTC), 2, now)
+	printAgenda(&out, overdue, agenda, now)
+	want = "Overdue\n" +
+		"    1  Pay rent  (1 day overdue)\n" +
+		"\n" +
+		"Wed Apr 2 (today)\n" +
+		"     -\n" +
+		"Thu Apr 3\n" +
+		"    2  15:00  Demo  [H]\n"
+	if out.String() != want {
+		t.Errorf("agenda =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestIDRanges(t *testing.T) {
+	for _, tc := range []struct {
+		ids  []int
+		want string
+	}{
+		{[]int{1, 2, 3, 4, 7, 9, 10}, "1-4, 7, 9-10"},
+		{[]int{5}, "5"},
+		{[]int{12, 3, 4}, "12, 3-4"},
+	} {
+		if got := idRanges(tc.ids); got != tc.want {
+			t.Errorf("idRanges(%v) = %q, want %q", tc.ids, got, tc.want)
+		}
+	}
+}
+
+// inTempStore runs the rest of the test in an empty directory, where the
+// commands keep their files, with the flags reset afterwards.
+func inTempStore(t *testing.T) {
+	dir, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(dir)
+		resetFlags(rootCmd)
+	})
+}
+
+func TestExportMarkdownGroupedByTag(t *testing.T) {
+	inTempStore(t)
+	tasks := []todo.Task{
+		{ID: 1, Description: "Pay rent", Tags: []string{"home"}, CreatedAt: time.Now()},
+		{ID: 2, Description: "Fix CI", Tags: []string{"work", "home"}, CreatedAt: time.Now()},
+		{ID: 3, Description: "Call mom", Completed: true, CreatedAt: time.Now(), CompletedAt: time.Now()},
+	}
+	if err := todo.SaveTasks("tasks.csv", tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureOutput(func() {
+		rootCmd.SetArgs([]string{"export", "-f", "markdown", "--group-by", "tag"})
+		rootCmd.Execute()
+	})
+	headings, items := []string{}, []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			headings = append(headings, line)
+		} else if text, _, ok := strings.Cut(line, " <!-- r2d2:"); ok {
+			items = append(items, text)
+		}
+	}
+	wantHeadings := []string{"## +home", "## +work", "## (no tag)"}
+	wantItems := []string{"- [ ] Pay rent +home", "- [ ] Fix CI +work +home", "- [ ] Fix CI +work +home", "- [x] Call mom"}
+	if strings.Join(headings, "|") != strings.Join(wantHeadings, "|") || strings.Join(items, "|") != strings.Join(wantItems, "|") {
+		t.Errorf("export =\n%s", output)
+	}
+}
+
+func TestCompleteAutoParentRecurs(t *testing.T) {
+	inTempStore(t)
+	now := time.Now()
+	tasks := []todo.Task{
+		{ID: 1, Description: "Weekly review", CreatedAt: now, Due: todo.EndOfDay(now), Recur: "FREQ=WEEKLY"},
+		{ID: 2, Description: "Inbox zero", CreatedAt: now, ParentID: 1},
+	}
+	if err := todo.SaveTasks("tasks.csv", tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureOutput(func() {
+		rootCmd.SetArgs([]string{"complete", "--auto-parent", "2"})
+		rootCmd.Execute()
+	})
+	tasks, err := todo.LoadTasks("tasks.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 || !tasks[0].Completed || tasks[2].RecurParent != 1 || tasks[2].Completed {
+		t.Errorf("tasks = %+v\noutput:\n%s", tasks, output)
+	}
+}
+
+func TestSnoozeCompletedTask(t *testing.T) {
+	inTempStore(t)
+	now := time.Now()
+	tasks := []todo.Task{{ID: 1, Description: "Done already", CreatedAt: now, Completed: true, CompletedAt: now}}
+	if err := todo.SaveTasks("tasks.csv", tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureOutput(func() {
+		rootCmd.SetArgs([]string{"snooze", "1", "3d"})
+		rootCmd.Execute()
+	})
+	if !strings.Contains(output, "Task 1 is already completed") {
+		t.Errorf("output = %q", output)
+	}
+	tasks, err := todo.LoadTasks("tasks.csv")
+	if err != nil || !tasks[0].Wait.IsZero() {
+		t.Errorf("the completed task was snoozed: %+v, %v", tasks, err)
+	}
+}
+
+// Every command that changes tasks is in the history, so undo takes back
+// the latest change and not an older one.
+func TestUndoCoversEveryCommand(t *testing.T) {
+	inTempStore(t)
+	if err := todo.SaveTasks("tasks.csv", nil); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		return captureOutput(func() {
+			rootCmd.SetArgs(args)
+			rootCmd.Execute()
+			resetFlags(rootCmd)
+		})
+	}
+	run("add", "one")
+	run("complete", "1")
+	run("add", "two")
+	run("annotate", "2", "a note")
+	run("start", "2")
+	run("stop")
+	run("tags", "rename", "x", "y")
+
+	for _, want := range []string{"Undid stop", "Undid start", "Undid annotate", "Undid add"} {
+		if output := run("undo"); !strings.Contains(output, want) {
+			t.Errorf("undo = %q, want %q", output, want)
+		}
+	}
+	tasks, err := todo.LoadTasks("tasks.csv")
+	if err != nil || len(tasks) != 1 || !tasks[0].Completed {
+		t.Errorf("tasks = %+v, %v", tasks, err)
+	}
+}
@@ -3,20 +3,25 @@ package cmd
 import (
 	"R2-D2/todo"
 	"fmt"
-	"strconv"
+	"sort"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var forceCompleteFlag bool
+var autoParentFlag bool
+
 var completeCmd = &cobra.Command{
-	Use:   "complete [task ID]",
-	Short: "Complete a task",
-	Args:  cobra.ExactArgs(1),
+	Use:   "complete [task ID or filter]",
+	Short: "Complete tasks",
+	Long: `Complete the tasks picked out by IDs or a filter (see "list --help"),
+e.g. "complete 3", "complete 3 5 8-12" or "complete +errands due:today".`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
+		cfg, err := todo.LoadConfig("config.json")
 		if err != nil {
-			fmt.Println("Error converting task ID to int:", err)
+			fmt.Println("Error loading config:", err)
 			return
 		}
 		tasks, err := todo.LoadTasks("tasks.csv")
@@ -24,20 +29,99 @@ var completeCmd = &cobra.Command{
 			fmt.Println("Error loading tasks:", err)
 			return
 		}
-		for i, task := range tasks {
-			if task.ID == id {
-				tasks[i].Completed = true
-				tasks[i].CompletedAt = time.Now()
-				err = todo.SaveTasks("tasks.csv", tasks)
-				if err != nil {
-					fmt.Println("Error saving tasks:", err)
+		now := time.Now()
+		ids, ok := selectTasks(args, tasks, todo.Context{Tasks: tasks, Config: cfg, Now: now})
+		if !ok || !confirmBulk("complete", ids, tasks, cfg) {
+			return
+		}
+		before := todo.CloneTasks(tasks)
+
+		// Subtasks go before their parents so a parent selected along with
+		// its children is not refused for having them open
+		selected := []todo.Task{}
+		for _, task := range tasks {
+			if containsID(ids, task.ID) {
+				selected = append(selected, task)
+			}
+		}
+		order := todo.TreeOrder(selected)
+		sort.SliceStable(order, func(a, b int) bool { return order[a].Depth > order[b].Depth })
+
+		messages := []string{}
+		// Completing an occurrence of a recurring task spawns the next one
+		spawnNext := func(task todo.Task) {
+			next, ok, err := todo.NextOccurrence(tasks, task, now)
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("Error computing next occurrence: %v", err))
+			} else if ok {
+				tasks = append(tasks, next)
+				messages = append(messages, fmt.Sprintf("Next occurrence: %d due %s", next.ID, next.Due.Format("2006-01-02 15:04")))
+			}
+		}
+		done := map[int]bool{}
+		for _, node := range order {
+			id := node.Task.ID
+			i := taskIndex(tasks, id)
+			if tasks[i].Completed {
+				if done[id] {
+					continue
 				}
+				messages = append(messages, fmt.Sprintf("Task %d is already completed", id))
+				continue
+			}
+			if open := todo.OpenChildren(tasks, id); len(open) > 0 && !forceCompleteFlag {
+				messages = append(messages, fmt.Sprintf("Task %d has %d open subtask(s); complete them first or use --force", id, len(open)))
+				continue
+			}
+
+			if active := todo.ActiveTimer(tasks); active == i {
+				todo.StopTimer(tasks, now)
 			}
+			tasks[i].Completed = true
+			tasks[i].CompletedAt = now
+			messages = append(messages, fmt.Sprintf("Task %d completed", id))
+
+			if autoParentFlag {
+				for _, parentID := range todo.CompletableParents(tasks, id) {
+					j := taskIndex(tasks, parentID)
+					tasks[j].Completed = true
+					tasks[j].CompletedAt = now
+					done[parentID] = true
+					messages = append(messages, fmt.Sprintf("Task %d completed (all subtasks done)", parentID))
+					spawnNext(tasks[j])
+				}
+			}
+
+			spawnNext(tasks[i])
 		}
-		fmt.Printf("Task %d completed\n", id)
+
+		reportChanges("complete", before, tasks, messages)
 	},
 }
 
+// taskIndex returns the position of the task with the given ID, or -1.
+func taskIndex(tasks []todo.Task, id int) int {
+	for i, task := range tasks {
+		if task.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// containsID reports whether id is in the list.
+func containsID(ids []int, id int) bool {
+	for _, n := range ids {
+		if n == id {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	rootCmd.AddCommand(completeCmd)
+	completeCmd.Flags().BoolVarP(&forceCompleteFlag, "force", "f", false, "Complete tasks even if they have open subtasks")
+	addBulkFlags(completeCmd)
+	completeCmd.Flags().BoolVar(&autoParentFlag, "auto-parent", false, "Also complete parents whose subtasks are now all done")
 }
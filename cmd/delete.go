@@ -3,19 +3,27 @@ package cmd
 import (
 	"R2-D2/todo"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var cascadeFlag bool
+
 var deleteCmd = &cobra.Command{
-	Use:   "delete [task ID]",
-	Short: "Delete a task",
-	Args:  cobra.ExactArgs(1),
+	Use:   "delete [task ID or filter]",
+	Short: "Delete tasks",
+	Long: `Delete the tasks picked out by IDs or a filter (see "list --help").
+Subtasks move up to the deleted task's parent, or are deleted along with
+it when --cascade is given.
+
+  R2-D2 delete 7
+  R2-D2 delete status:done completed.before:2025-01-01`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
+		cfg, err := todo.LoadConfig("config.json")
 		if err != nil {
-			fmt.Println("Error converting task ID to int:", err)
+			fmt.Println("Error loading config:", err)
 			return
 		}
 		tasks, err := todo.LoadTasks("tasks.csv")
@@ -23,22 +31,32 @@ var deleteCmd = &cobra.Command{
 			fmt.Println("Error loading tasks:", err)
 			return
 		}
-		for i, task := range tasks {
-			if task.ID == id {
-				tasks = append(tasks[:i], tasks[i+1:]...)
-				err = todo.SaveTasks("tasks.csv", tasks)
-				if err != nil {
-					fmt.Println("Error saving tasks:", err)
-					return
-				}
-				fmt.Printf("Task %d deleted\n", id)
-				return
+		ids, ok := selectTasks(args, tasks, todo.Context{Tasks: tasks, Config: cfg, Now: time.Now()})
+		if !ok || !confirmBulk("delete", ids, tasks, cfg) {
+			return
+		}
+		before := todo.CloneTasks(tasks)
+
+		removed := []int{}
+		for _, id := range ids {
+			// A cascade may already have taken this one
+			if !taskExists(tasks, id) {
+				continue
 			}
+			var gone []int
+			tasks, gone = todo.RemoveTask(tasks, id, cascadeFlag)
+			removed = append(removed, gone...)
+		}
+		messages := []string{}
+		for _, removedID := range removed {
+			messages = append(messages, fmt.Sprintf("Task %d deleted", removedID))
 		}
-		fmt.Printf("Task %d not found\n", id)
+		reportChanges("delete", before, tasks, messages)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	addBulkFlags(deleteCmd)
+	deleteCmd.Flags().BoolVar(&cascadeFlag, "cascade", false, "Also delete the task's subtasks")
 }
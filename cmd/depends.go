@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var dependsOnFlags []int
+var dependsRemoveFlags []int
+
+var dependsCmd = &cobra.Command{
+	Use:   "depends [task ID]",
+	Short: "Show or change the tasks a task is waiting on",
+	Example: `  R2-D2 depends 7 --on 3,4
+  R2-D2 depends 7 --remove 4`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error converting task ID to int:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		before := todo.CloneTasks(tasks)
+
+		if len(dependsOnFlags) > 0 || len(dependsRemoveFlags) > 0 {
+			if err := todo.RemoveDependencies(tasks, id, dependsRemoveFlags); err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if err := todo.AddDependencies(tasks, id, dependsOnFlags); err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if err := saveTasks("depends", before, tasks); err != nil {
+				fmt.Println("Error saving tasks:", err)
+				return
+			}
+		}
+
+		for _, task := range tasks {
+			if task.ID != id {
+				continue
+			}
+			if len(task.DependsOn) == 0 {
+				fmt.Printf("Task %d has no dependencies\n", id)
+				return
+			}
+			ids := []string{}
+			for _, dep := range task.DependsOn {
+				ids = append(ids, strconv.Itoa(dep))
+			}
+			fmt.Printf("Task %d depends on: %s\n", id, strings.Join(ids, ", "))
+			return
+		}
+		fmt.Printf("Task %d not found\n", id)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dependsCmd)
+	dependsCmd.Flags().IntSliceVar(&dependsOnFlags, "on", nil, "IDs of tasks that must be done first")
+	dependsCmd.Flags().IntSliceVar(&dependsRemoveFlags, "remove", nil, "IDs of dependencies to drop")
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit [task ID or filter]",
+	Short: "Edit tasks in $EDITOR",
+	Long: `Open the tasks picked out by IDs or a filter (see "list --help") in
+$VISUAL or $EDITOR as "field: value" lines, and apply what changed once the
+editor exits. Mistakes are pointed out and the file reopened to fix them.
+
+The file, tasks.txt, is plain text rather than YAML: each task is an
+"id:" line followed by one "field: value" line per field, and tasks are
+separated by "---" lines. The value is everything after the first colon,
+less surrounding spaces, with no quoting or escaping; an empty value
+clears the field.
+Lines starting with # are ignored.
+
+Secret tasks appear decrypted in a file only you can read, which is wiped
+and removed as soon as the editor exits.`,
+	Example: `  R2-D2 edit 4
+  R2-D2 edit +release status:pending`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		ids, ok := selectTasks(args, tasks, todo.Context{Tasks: tasks, Config: cfg, Now: time.Now()})
+		if !ok {
+			return
+		}
+
+		selected := []todo.Task{}
+		for _, id := range ids {
+			selected = append(selected, tasks[taskIndex(tasks, id)])
+		}
+		original, err := todo.FormatEditDoc(selected, cfg)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		text := original
+		for {
+			edited, err := editText(text)
+			if err != nil {
+				fmt.Println("Error running editor:", err)
+				return
+			}
+			if edited == original {
+				fmt.Println("No changes")
+				return
+			}
+			updated, changed, err := todo.ApplyEdits(tasks, edited, cfg, time.Now)
+			if err == nil {
+				if len(changed) == 0 {
+					fmt.Println("No changes")
+					return
+				}
+				if err := saveTasks("edit", tasks, updated); err != nil {
+					fmt.Println("Error saving tasks:", err)
+					return
+				}
+				for _, id := range changed {
+					fmt.Printf("Task %d updated\n", id)
+				}
+				return
+			}
+
+			fmt.Println("The edited tasks have errors:")
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Println("  " + line)
+			}
+			fmt.Print("Edit again? [Y/n] ")
+			answer := strings.ToLower(strings.TrimSpace(readLine(confirmInput)))
+			if answer == "n" || answer == "no" {
+				fmt.Println("Aborted: nothing was changed")
+				return
+			}
+			text = withEditErrors(edited, err)
+		}
+	},
+}
+
+const editErrorPrefix = "# error: "
+
+// withEditErrors appends the errors to the edited text as comments,
+// replacing those of the previous round. They go at the end so the line
+// numbers they mention stay right.
+func withEditErrors(text string, err error) string {
+	kept := []string{}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if !strings.HasPrefix(line, editErrorPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	for _, line := range strings.Split(err.Error(), "\n") {
+		kept = append(kept, editErrorPrefix+line)
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// editText lets the user change text in their editor. The file lives in a
+// private directory, so editor swap and backup files stay private too, and
+// is overwritten with zeros before everything is removed.
+func editText(text string) (string, error) {
+	dir, err := os.MkdirTemp("", "r2d2-edit-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tasks.txt")
+	defer wipeFile(path)
+	if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	c := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v", editor, err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.New("failed to read the edited file")
+	}
+	return string(edited), nil
+}
+
+// wipeFile overwrites a file with zeros so its contents do not linger on
+// disk once it is removed.
+func wipeFile(path string) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	if info, err := f.Stat(); err == nil {
+		f.Write(make([]byte, info.Size()))
+		f.Sync()
+	}
+	f.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var exportFormatFlag string
+var exportSecretsFlag bool
+var exportGroupByFlag string
+
+var exportCmd = &cobra.Command{
+	Use:   "export [filter]",
+	Short: "Write tasks to standard output in another format",
+	Long: `Write all tasks, or those matching a filter, to standard output.
+
+Formats:
+  json     everything in the store: tasks exactly as saved, secret ones
+           still encrypted, and the history (left out when filtering),
+           with a schema version; "import" reads it back
+  jsonl    the same as JSON Lines: a header line, then one line per task
+           and history entry
+  ics      an iCalendar file of VTODO items for calendar clients, with
+           due and scheduled dates, priority, tags, notes and recurrence
+  markdown "- [ ]" and "- [x]" checklists, under a heading per group with
+           --group-by (status, tag, due-week, parent or a custom field;
+           "list" is the same as parent, whose subtasks make a list).
+           Items carry a hidden comment with the task ID, so the file
+           can be kept in step with "sync-md"
+  todotxt  one todo.txt line per task: priority (A)-(C) for H-L, creation
+           and completion dates, +projects and @contexts from tags, and
+           due:, t: (wait), scheduled:, estimate:, rrule:, note: and custom
+           fields as key:value pairs. Parents, dependencies, time logs and
+           paused series have no place in it; a notice on standard error
+           names the tasks that lose them
+
+In the ics, markdown and todotxt formats secret tasks are left out unless -d is
+given, which writes them decrypted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(exportFormatFlag)
+		if format != "json" && format != "jsonl" && format != "ics" && format != "markdown" && format != "todotxt" {
+			fmt.Printf("Unknown export format %q: use json, jsonl, ics, markdown or todotxt\n", exportFormatFlag)
+			return
+		}
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		filter, ok := parseFilterArgs(args, cfg)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		ctx := todo.Context{Tasks: tasks, Config: cfg, Now: now, Reveal: exportSecretsFlag}
+		if format == "json" || format == "jsonl" {
+			dump := todo.Dump{Version: todo.DumpVersion, ExportedAt: now, Tasks: filter.Apply(tasks, ctx)}
+			if filter.Empty() {
+				if dump.History, err = todo.LoadHistory("history.csv"); err != nil {
+					fmt.Println("Error loading history:", err)
+					return
+				}
+			}
+			if err := todo.WriteDump(os.Stdout, dump, format == "jsonl"); err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing tasks:", err)
+			}
+			return
+		}
+
+		selected, skipped := []todo.Task{}, 0
+		for _, task := range filter.Apply(tasks, ctx) {
+			if task.Encrypted {
+				if !exportSecretsFlag {
+					skipped++
+					continue
+				}
+				task = decryptTask(task)
+			}
+			selected = append(selected, task)
+		}
+
+		switch format {
+		case "ics":
+			err = todo.WriteICS(os.Stdout, selected, now)
+		case "markdown":
+			groups := []todo.Group{{Tasks: selected}}
+			if exportGroupByFlag != "" {
+				if groups, err = todo.GroupTasks(selected, strings.ToLower(exportGroupByFlag), ctx); err != nil {
+					fmt.Println("Error:", err)
+					return
+				}
+			}
+			err = todo.WriteMarkdown(os.Stdout, groups)
+		default:
+			err = todo.WriteTodoTxt(os.Stdout, selected)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing tasks:", err)
+			return
+		}
+		// Notices go to standard error so they stay out of redirected output
+		if format == "todotxt" {
+			for _, task := range selected {
+				if dropped := todo.TodoTxtDropped(task); len(dropped) > 0 {
+					fmt.Fprintf(os.Stderr, "Task %d: todo.txt has no place for its %s\n", task.ID, strings.Join(dropped, ", "))
+				}
+			}
+		}
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Left out %d secret task(s); use -d to include them decrypted\n", skipped)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportFormatFlag, "format", "f", "json", "Output format: json, jsonl, ics, markdown or todotxt")
+	exportCmd.Flags().StringVar(&exportGroupByFlag, "group-by", "", "Group markdown checklists under headings by status, tag, due-week, parent (or list) or a custom field")
+	exportCmd.Flags().BoolVarP(&exportSecretsFlag, "show-secrets", "d", false, "Include secret tasks, decrypted")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterQuery joins command arguments into a filter query. Values with
+// spaces need quotes that survive the shell: desc~'"deploy now"'.
+func filterQuery(args []string) string {
+	return strings.Join(args, " ")
+}
+
+// parseFilterArgs compiles the filter given as arguments. Errors are
+// printed with a caret under the column where parsing failed.
+func parseFilterArgs(args []string, cfg todo.Config) (*todo.Filter, bool) {
+	filter, err := todo.ParseFilter(filterQuery(args), cfg, time.Now)
+	if err != nil {
+		var perr *todo.ParseError
+		if errors.As(err, &perr) {
+			fmt.Printf("Invalid filter at column %d: %s\n", perr.Column, perr.Msg)
+			for _, line := range strings.Split(perr.Caret(), "\n") {
+				fmt.Println("  " + line)
+			}
+		} else {
+			fmt.Println("Invalid filter:", err)
+		}
+		return nil, false
+	}
+	return filter, true
+}
+
+// selectTasks returns the IDs of the tasks a command argument list picks
+// out. An empty selection is reported and returns false, so commands that
+// change tasks never act on everything by accident.
+func selectTasks(args []string, tasks []todo.Task, ctx todo.Context) ([]int, bool) {
+	filter, ok := parseFilterArgs(args, ctx.Config)
+	if !ok {
+		return nil, false
+	}
+	if filter.Empty() {
+		fmt.Println("Give a task ID or a filter")
+		return nil, false
+	}
+	ids := []int{}
+	for _, task := range filter.Apply(tasks, ctx) {
+		ids = append(ids, task.ID)
+	}
+	if len(ids) == 0 {
+		if id, err := strconv.Atoi(strings.TrimSpace(filterQuery(args))); err == nil {
+			fmt.Printf("Task %d not found\n", id)
+		} else {
+			fmt.Println("No tasks match the filter")
+		}
+		return nil, false
+	}
+	return ids, true
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var dotFlag bool
+var graphAllFlag bool
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print tasks in dependency order, or as a Graphviz graph",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		if !graphAllFlag {
+			pending := []todo.Task{}
+			for _, task := range tasks {
+				if !task.Completed {
+					pending = append(pending, task)
+				}
+			}
+			tasks = pending
+		}
+
+		if dotFlag {
+			fmt.Print(todo.DependencyDOT(tasks, func(task todo.Task) string {
+				return displayDescription(task, false)
+			}))
+			return
+		}
+
+		ordered, err := todo.TopoSort(tasks)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if len(ordered) == 0 {
+			fmt.Println("No tasks to display")
+			return
+		}
+		for i, task := range ordered {
+			status := " "
+			if task.Completed {
+				status = "X"
+			}
+			fmt.Printf("%d. [%s] %d - %s\n", i+1, status, task.ID, displayDescription(task, false))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().BoolVar(&dotFlag, "dot", false, "Print the graph in Graphviz DOT format")
+	graphCmd.Flags().BoolVar(&graphAllFlag, "all", false, "Include completed tasks")
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var historyLimitFlag int
+var undoForceFlag bool
+
+var historyCmd = &cobra.Command{
+	Use:   "history [task ID]",
+	Short: "Show the changes made to tasks",
+	Long: `Show the changes commands made to tasks, most recent last, optionally
+only those to one task.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		taskID := 0
+		if len(args) == 1 {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Println("Error converting task ID to int:", err)
+				return
+			}
+			taskID = id
+		}
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		history, err := todo.LoadHistory("history.csv")
+		if err != nil {
+			fmt.Println("Error loading history:", err)
+			return
+		}
+
+		ops := [][]todo.HistoryEntry{}
+		for _, entry := range history {
+			if taskID != 0 && entry.TaskID != taskID {
+				continue
+			}
+			if n := len(ops); n > 0 && ops[n-1][0].Op == entry.Op {
+				ops[n-1] = append(ops[n-1], entry)
+			} else {
+				ops = append(ops, []todo.HistoryEntry{entry})
+			}
+		}
+		if len(ops) == 0 {
+			fmt.Println("No history")
+			return
+		}
+		if historyLimitFlag > 0 && len(ops) > historyLimitFlag {
+			ops = ops[len(ops)-historyLimitFlag:]
+		}
+		for _, op := range ops {
+			first := op[0]
+			command := first.Command
+			if first.Undoes != 0 {
+				command = fmt.Sprintf("%s of %d", command, first.Undoes)
+			}
+			fmt.Printf("%4d  %s  %s\n", first.Op, first.At.Local().Format("2006-01-02 15:04"), command)
+			for _, entry := range op {
+				fmt.Println("      " + describeEntry(entry.Before, entry.After, cfg))
+			}
+		}
+	},
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Take back the last change",
+	Long: `Put the tasks the last modify, edit, complete or delete changed back the
+way they were. Run it again to go further back. A task that has changed
+since is left alone unless --force is given.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		history, err := todo.LoadHistory("history.csv")
+		if err != nil {
+			fmt.Println("Error loading history:", err)
+			return
+		}
+		op := todo.LastUndoable(history)
+		if op == 0 {
+			fmt.Println("Nothing to undo")
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		restored, err := todo.Undo(tasks, history, op, undoForceFlag)
+		if err != nil {
+			fmt.Printf("Cannot undo: %v; use --force to undo anyway\n", err)
+			return
+		}
+		if err := todo.SaveTasks("tasks.csv", restored); err != nil {
+			fmt.Println("Error saving tasks:", err)
+			return
+		}
+
+		if err := todo.RecordHistory("history.csv", "undo", op, tasks, restored, time.Now()); err != nil {
+			fmt.Println("Warning: the undo was saved but not added to the history:", err)
+		}
+		first := true
+		for _, entry := range history {
+			if entry.Op != op {
+				continue
+			}
+			if first {
+				fmt.Printf("Undid %s (%d)\n", entry.Command, op)
+				first = false
+			}
+			fmt.Println("  " + describeEntry(entry.After, entry.Before, cfg))
+		}
+	},
+}
+
+// describeEntry says what happened to a task between two versions of it;
+// a missing version means it was added or deleted.
+func describeEntry(before, after *todo.Task, cfg todo.Config) string {
+	switch {
+	case before == nil:
+		return fmt.Sprintf("Task %d added: %s", after.ID, displayDescription(*after, false))
+	case after == nil:
+		return fmt.Sprintf("Task %d deleted: %s", before.ID, displayDescription(*before, false))
+	}
+	diff := todo.DiffTask(*before, *after, cfg)
+	if len(diff) == 0 {
+		return fmt.Sprintf("Task %d changed", after.ID)
+	}
+	return fmt.Sprintf("Task %d: %s", after.ID, formatFieldChanges(diff))
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd, undoCmd)
+	historyCmd.Flags().IntVarP(&historyLimitFlag, "limit", "n", 20, "Show at most this many of the latest changes")
+	undoCmd.Flags().BoolVar(&undoForceFlag, "force", false, "Undo even if the tasks have changed since")
+}
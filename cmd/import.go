@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var importFormatFlag string
+var importConflictFlag string
+var importDryRunFlag bool
+
+var importCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Add tasks from a file in another format",
+	Long: `Add the tasks in FILE, or standard input when FILE is "-", to the store.
+"undo" takes a whole import back.
+
+Formats:
+  json     an export made with "export --format json", from this or another
+           machine. Tasks keep their IDs unless those are taken: a task
+           identical to the one stored is skipped, any other is a conflict
+           that --on-conflict remap (the default) gives a new ID and fail
+           refuses. Parent and dependency links follow renumbered tasks;
+           links to tasks outside the export are dropped. Its history is
+           restored only into an empty store.
+  jsonl    the same as JSON Lines
+  ics      the VTODO items of an iCalendar file, added with new IDs. Times
+           with a time zone Go does not know are read in the local one
+  markdown the "- [ ]" and "- [x]" checklist items of a Markdown file,
+           added with new IDs; +tag and @context words become tags. To
+           keep a file and the store in step, use "sync-md" instead
+  todotxt  todo.txt lines, as written by "export --format todotxt", added
+           with new IDs. Keys r2d2 has no field for are kept as custom
+           fields and written back on export.
+
+Without --format, files ending in .jsonl, .ics, .md or .txt are read in those
+formats and anything else as JSON. Nothing is imported if anything in the file is invalid. --dry-run shows
+what would happen without changing anything.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(importFormatFlag)
+		if !cmd.Flags().Changed("format") {
+			format = formatFromFile(args[0])
+		}
+		if format != "json" && format != "jsonl" && format != "ics" && format != "markdown" && format != "todotxt" {
+			fmt.Printf("Unknown import format %q: use json, jsonl, ics, markdown or todotxt\n", importFormatFlag)
+			return
+		}
+		if importConflictFlag != "remap" && importConflictFlag != "fail" {
+			fmt.Printf("Unknown --on-conflict %q: use remap or fail\n", importConflictFlag)
+			return
+		}
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		history, err := todo.LoadHistory("history.csv")
+		if err != nil {
+			fmt.Println("Error loading history:", err)
+			return
+		}
+
+		var input io.Reader = os.Stdin
+		if args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				fmt.Println("Error opening file:", err)
+				return
+			}
+			defer file.Close()
+			input = file
+		}
+		var dump todo.Dump
+		switch format {
+		case "todotxt":
+			dump.Tasks, err = todo.ReadTodoTxt(input, cfg, time.Now)
+		case "markdown":
+			dump.Tasks, err = todo.ReadMarkdown(input, time.Now())
+		case "ics":
+			dump.Tasks, err = todo.ReadICS(input, func() time.Time { return time.Now().In(cfg.Location()) })
+		default:
+			dump, err = todo.ReadDump(input, format == "jsonl")
+		}
+		if err != nil {
+			fmt.Println("Error reading tasks:")
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Println("  " + line)
+			}
+			return
+		}
+
+		result, err := todo.MergeTasks(tasks, dump.Tasks, importConflictFlag == "remap")
+		var conflict *todo.ConflictError
+		if errors.As(err, &conflict) {
+			fmt.Printf("Nothing imported: %v. Use --on-conflict remap to give them new IDs\n", err)
+			return
+		}
+		if err != nil {
+			fmt.Println("Nothing imported:", err)
+			return
+		}
+		// Someone else's history would make undo revert their commands
+		// here, so it only comes along into an empty store
+		restore := len(dump.History) > 0 && len(tasks) == 0 && len(history) == 0
+
+		printImportSummary(result, dump.History, restore, importDryRunFlag)
+		if importDryRunFlag || len(result.Added) == 0 {
+			return
+		}
+		if restore {
+			if err := todo.AppendHistory("history.csv", dump.History); err != nil {
+				fmt.Println("Error restoring history:", err)
+				return
+			}
+		}
+		if err := saveTasks("import", tasks, result.Tasks); err != nil {
+			fmt.Println("Error saving tasks:", err)
+		}
+	},
+}
+
+func printImportSummary(result todo.MergeResult, history []todo.HistoryEntry, restore, dryRun bool) {
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+	switch len(result.Added) {
+	case 0:
+		fmt.Println("Nothing to import")
+	case 1:
+		fmt.Printf("%s 1 task (ID %d)\n", verb, result.Added[0])
+	default:
+		fmt.Printf("%s %d tasks (IDs %s)\n", verb, len(result.Added), idRanges(result.Added))
+	}
+	if len(result.Remapped) > 0 {
+		moves := []string{}
+		for _, id := range result.Added {
+			for old, now := range result.Remapped {
+				if now == id {
+					moves = append(moves, fmt.Sprintf("%d -> %d", old, now))
+				}
+			}
+		}
+		fmt.Printf("  renumbered, as the IDs were taken: %s\n", strings.Join(moves, ", "))
+	}
+	if len(result.Present) > 0 {
+		fmt.Printf("  already present, skipped: %s\n", idRanges(result.Present))
+	}
+	if result.Dropped > 0 {
+		fmt.Printf("  links to tasks outside the import dropped: %d\n", result.Dropped)
+	}
+	switch {
+	case len(history) == 0:
+	case restore && dryRun:
+		fmt.Printf("  history: %d entries would be restored\n", len(history))
+	case restore:
+		fmt.Printf("  history: %d entries restored\n", len(history))
+	default:
+		fmt.Printf("  history: %d entries left out, as this store has its own\n", len(history))
+	}
+	if dryRun {
+		fmt.Println("Dry run: nothing was changed")
+	}
+}
+
+// formatFromFile picks the import format a file name suggests.
+func formatFromFile(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl":
+		return "jsonl"
+	case ".ics":
+		return "ics"
+	case ".md", ".markdown":
+		return "markdown"
+	case ".txt":
+		return "todotxt"
+	}
+	return "json"
+}
+
+// idRanges lists IDs with runs collapsed, e.g. "1-4, 7, 9-10".
+func idRanges(ids []int) string {
+	parts := []string{}
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(ids[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&importFormatFlag, "format", "f", "json", "Input format: json, jsonl, ics, markdown or todotxt (default from the file name)")
+	importCmd.Flags().StringVar(&importConflictFlag, "on-conflict", "remap", "What to do with IDs already taken: remap or fail")
+	importCmd.Flags().BoolVarP(&importDryRunFlag, "dry-run", "n", false, "Show what would be imported without changing anything")
+}
@@ -2,74 +2,253 @@ package cmd
 
 import (
 	"R2-D2/todo"
+	"bytes"
 	"fmt"
-	"os"
-	"text/tabwriter"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var showSecretsFlag bool
+var overdueFlag bool
+var dueBeforeFlag string
+var treeFlag bool
+var readyFlag bool
+var searchFlag string
+var sortFlag string
+var waitingFlag bool
+var outputFlag string
+var columnsFlag string
+var templateFlag string
+var groupByFlag string
+var limitFlag int
+var offsetFlag int
+var noPagerFlag bool
 
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [filter]",
 	Short: "List all tasks",
+	Long: `List tasks, optionally narrowed down by a filter such as
+
+  +work ^home
+  status:pending and (tag:work or priority>=H)
+  created.after:2025-04-01 desc~"deploy"
+  due.before:eow or status:overdue
+  3 5 8-12
+
+Terms are joined with and, or and not and grouped with parentheses. Fields
+are id, status (pending, done, waiting, ready, blocked, overdue), priority,
+tag, desc, created, completed, due, wait, scheduled, urgency, parent,
+depends and custom fields, compared with : = != < <= > >= ~ (contains) and
+!~. Dates take .before, .after, .is and .not. Plain words search the
+description and notes. ^tag leaves out tasks with the tag; so does -tag
+after "--", where it can't be read as flags.
+
+--template prints each task through a Go text/template, or through a
+template of that name from config.json:
+
+  R2-D2 list --template '{{.ID | padLeft 3}} {{.Description | truncate 40}} {{.Due | relative}}'
+
+A task has ID, Description, Status, Completed, Overdue, Priority, Tags,
+CreatedAt, CompletedAt, Due, Wait, Scheduled, Urgency, ParentID,
+DependsOn, Estimate, Spent, Recur, Secret and Fields. Helpers:
+
+  relative TIME       "today", "in 3 days", "2 days overdue"
+  ago TIME            "5m ago", "3d ago"
+  date LAYOUT TIME    Go time layout, empty for unset dates
+  duration D          "2h30m"
+  color NAME X        red, green, yellow, blue, magenta, cyan, gray, bold...
+                      (plain when NO_COLOR is set)
+  pad N X, padLeft N X, truncate N X
+  default FALLBACK X, upper S, lower S, join SEP LIST
+
+--group-by puts tasks under a heading per status, tag, due-week, parent or
+custom field value. A parent task is how r2d2 keeps a list of subtasks, so
+"list" is the same as "parent".`,
 	Run: func(cmd *cobra.Command, args []string) {
-		tasks, err := todo.LoadTasks("tasks.csv")
+		cfg, err := todo.LoadConfig("config.json")
 		if err != nil {
-			fmt.Println("Error loading tasks:", err)
+			fmt.Println("Error loading config:", err)
 			return
 		}
 
-		if len(tasks) == 0 {
-			fmt.Println("No tasks to display")
+		filter, ok := parseFilterArgs(args, cfg)
+		if !ok {
+			return
+		}
+		format := strings.ToLower(outputFlag)
+		columns, err := resolveColumns(columnsFlag, format, cfg)
+		if err != nil {
+			fmt.Println("Error:", err)
 			return
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		if groupByFlag != "" && templateFlag == "" && format != "table" && format != "markdown" {
+			fmt.Println("--group-by works with the table and markdown formats or --template")
+			return
+		}
+		if offsetFlag < 0 || limitFlag < 0 {
+			fmt.Println("--limit and --offset cannot be negative")
+			return
+		}
 
-		fmt.Fprintln(w, "ID\tSTATUS\tDESCRIPTION\tCREATED AT\tSECRET")
+		now := time.Now()
+		var tmpl *template.Template
+		if templateFlag != "" {
+			if cmd.Flags().Changed("output") || columnsFlag != "" {
+				fmt.Println("Use either --template or --output/--columns")
+				return
+			}
+			tmpl, err = parseTemplate(templateFlag, cfg, now)
+			if err != nil {
+				fmt.Println("Error in template:", err)
+				return
+			}
+		}
 
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		dueBefore := time.Time{}
+		if dueBeforeFlag != "" {
+			dueBefore, err = todo.ParseDate(dueBeforeFlag, time.Now)
+			if err != nil {
+				fmt.Println("Error parsing --due-before:", err)
+				return
+			}
+		}
+
+		all := tasks
+		ctx := todo.Context{Tasks: all, Config: cfg, Now: now, Reveal: showSecretsFlag}
+		// Waiting tasks stay out of sight unless asked for
+		showWaiting := waitingFlag || filter.Uses("status") || filter.Uses("wait")
+		filtered := []todo.Task{}
 		for _, task := range tasks {
-			status := "Pending"
-			if task.Completed {
-				status = "Complete"
+			if overdueFlag && !task.Overdue(now) {
+				continue
+			}
+			if !dueBefore.IsZero() && (task.Due.IsZero() || !task.Due.Before(dueBefore)) {
+				continue
+			}
+			if (waitingFlag && !task.Waiting(now)) || (!showWaiting && task.Waiting(now)) {
+				continue
+			}
+			if readyFlag && !todo.Ready(all, task, now) {
+				continue
 			}
+			if searchFlag != "" && !todo.MatchText(task, searchFlag, showSecretsFlag) {
+				continue
+			}
+			if !filter.Match(task, ctx) {
+				continue
+			}
+			filtered = append(filtered, task)
+		}
+		tasks = filtered
 
-			createdTime := task.CreatedAt.Format("2006-01-02 15:04:05")
+		if err := todo.SortTasks(tasks, sortFlag, ctx); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
 
-			description := task.Description
-			secretStatus := "No"
+		total := len(tasks)
+		first := min(offsetFlag, total)
+		last := total
+		if limitFlag > 0 {
+			last = min(first+limitFlag, total)
+		}
+		tasks = tasks[first:last]
 
-			if task.Encrypted {
-				secretStatus = "Yes"
-				if showSecretsFlag {
-					// Decrypt the task description
-					decrypted, err := todo.DecryptText(task.Description)
-					if err != nil {
-						description = "[DECRYPT ERROR]"
-					} else {
-						description = decrypted
-					}
-				} else {
-					description = "[ENCRYPTED]"
+		groups := []todo.Group{{Tasks: tasks}}
+		if groupByFlag != "" {
+			groups, err = todo.GroupTasks(tasks, strings.ToLower(groupByFlag), ctx)
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if len(groups) == 0 {
+				groups = []todo.Group{{}}
+			}
+		}
+
+		var out bytes.Buffer
+		view := taskView{ctx: ctx, reveal: showSecretsFlag}
+		for i, group := range groups {
+			if group.Label != "" {
+				if i > 0 {
+					out.WriteString("\n")
 				}
+				fmt.Fprintf(&out, "%s (%d)\n", group.Label, len(group.Tasks))
 			}
 
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
-				task.ID,
-				status,
-				description,
-				createdTime,
-				secretStatus,
-			)
-		}
+			nodes := []todo.TreeNode{}
+			if treeFlag {
+				nodes = todo.TreeOrder(group.Tasks)
+			} else {
+				for _, task := range group.Tasks {
+					nodes = append(nodes, todo.TreeNode{Task: task})
+				}
+			}
 
-		w.Flush()
+			if tmpl != nil {
+				for _, node := range nodes {
+					if err := tmpl.Execute(&out, newTaskData(node.Task, view)); err != nil {
+						fmt.Println("Error in template:", err)
+						return
+					}
+					out.WriteString("\n")
+				}
+				continue
+			}
+			if err := renderTasks(&out, format, columns, nodes, view); err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+		}
+		if tmpl == nil && format == "table" && (offsetFlag > 0 || limitFlag > 0) && last > first {
+			fmt.Fprintf(&out, "Showing %d-%d of %d tasks\n", first+1, last, total)
+		}
+		writePaged(out.Bytes(), !noPagerFlag)
 	},
 }
 
+// displayDescription returns the text to show for a task: secret tasks read
+// "[ENCRYPTED]" unless reveal is set.
+func displayDescription(task todo.Task, reveal bool) string {
+	if !task.Encrypted {
+		return task.Description
+	}
+	if !reveal {
+		return "[ENCRYPTED]"
+	}
+	// Decrypt the task description
+	decrypted, err := todo.DecryptText(task.Description)
+	if err != nil {
+		return "[DECRYPT ERROR]"
+	}
+	return decrypted
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().BoolVarP(&showSecretsFlag, "show-secrets", "d", false, "Decrypt and display secret tasks")
+	listCmd.Flags().BoolVar(&overdueFlag, "overdue", false, "Only show open tasks past their due date")
+	listCmd.Flags().BoolVar(&waitingFlag, "waiting", false, "Only show tasks hidden until their wait date")
+	listCmd.Flags().BoolVar(&readyFlag, "ready", false, "Only show open tasks that are not waiting on other tasks")
+	listCmd.Flags().StringVar(&searchFlag, "search", "", "Only show tasks whose description or notes contain this text")
+	listCmd.Flags().StringVar(&sortFlag, "sort", "", "Sort keys, e.g. due,priority:desc (id, description, status, priority, urgency, created, completed, due, wait, scheduled, estimate or a custom field)")
+	listCmd.Flags().StringVar(&groupByFlag, "group-by", "", "Group under headings by status, tag, due-week, parent (or list) or a custom field")
+	listCmd.Flags().IntVar(&limitFlag, "limit", 0, "Show at most this many tasks")
+	listCmd.Flags().IntVar(&offsetFlag, "offset", 0, "Skip this many tasks first")
+	listCmd.Flags().BoolVar(&noPagerFlag, "no-pager", false, "Never send long output through $PAGER")
+	listCmd.Flags().StringVarP(&outputFlag, "output", "o", "table", "Output format: "+strings.Join(outputFormats, ", "))
+	listCmd.Flags().StringVar(&columnsFlag, "columns", "", "Comma-separated columns to show, e.g. id,status,description,due")
+	listCmd.Flags().StringVar(&templateFlag, "template", "", "Print each task with a Go template, or a template named in the config")
+	listCmd.Flags().BoolVar(&treeFlag, "tree", false, "Show subtasks indented under their parents")
+	listCmd.Flags().StringVar(&dueBeforeFlag, "due-before", "", "Only show tasks due before this date")
 }
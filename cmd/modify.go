@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var setFlags []string
+var unsetFlags []string
+var addTagFlags []string
+var removeTagFlags []string
+
+var modifyCmd = &cobra.Command{
+	Use:   "modify [task IDs or filter] [changes]",
+	Short: "Change fields of existing tasks",
+	Long: `Change the tasks picked out by IDs or a filter (see "list --help").
+
+The leading task IDs, or else the first argument as a filter, pick the
+tasks; every argument after that is a change:
+
+  field:value   set description (desc), priority, due, wait, scheduled,
+                estimate, tags, parent, depends or a custom field; an
+                empty value clears it
+  +tag, ^tag    add or remove a tag; -tag also removes it after "--"
+
+Changes are recorded in history.csv; see "history" and "undo".`,
+	Example: `  R2-D2 modify 4 desc:"Fix the login bug" priority:H due:friday +urgent ^later
+  R2-D2 modify 3 5 8-12 wait: scheduled:monday
+  R2-D2 modify 'tag:old and status:pending' +archived ^old
+  R2-D2 modify 4 --set customer=Acme --unset points
+  R2-D2 modify +acme status:pending --set customer=Acme`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		addTags, err := normalizeTags(addTagFlags)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		removeTags, err := normalizeTags(removeTagFlags)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		usesFlags := len(setFlags)+len(unsetFlags)+len(addTagFlags)+len(removeTagFlags) > 0
+		selection, rest := splitModifyArgs(args)
+		changes, err := parseChanges(rest, cfg)
+		if err != nil && usesFlags {
+			// With the changes given as flags, a filter may span arguments
+			selection, changes, err = args, modification{}, nil
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if changes.empty() && !usesFlags {
+			fmt.Println("Nothing to change: give changes such as priority:H or +tag, or use --set")
+			return
+		}
+
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		ids, ok := selectTasks(selection, tasks, todo.Context{Tasks: tasks, Config: cfg, Now: time.Now()})
+		if !ok || !confirmBulk("modify", ids, tasks, cfg) {
+			return
+		}
+
+		before := todo.CloneTasks(tasks)
+		messages := []string{}
+		for _, id := range ids {
+			i := taskIndex(tasks, id)
+			for _, field := range changes.fields {
+				if err := todo.SetTaskField(tasks, id, cfg, field.name, field.value, time.Now); err != nil {
+					fmt.Printf("Error on task %d: %v\n", id, err)
+					return
+				}
+			}
+			if err := setTaskFields(&tasks[i], cfg, setFlags); err != nil {
+				fmt.Printf("Error on task %d: %v\n", id, err)
+				return
+			}
+			for _, name := range unsetFlags {
+				delete(tasks[i].Fields, name)
+			}
+			tasks[i].Tags = todo.RemoveTags(todo.AddTags(tasks[i].Tags, append(changes.addTags, addTags...)...),
+				append(changes.removeTags, removeTags...)...)
+
+			diff := todo.DiffTask(before[taskIndex(before, id)], tasks[i], cfg)
+			if len(diff) == 0 {
+				messages = append(messages, fmt.Sprintf("Task %d unchanged", id))
+				continue
+			}
+			messages = append(messages, fmt.Sprintf("Task %d modified: %s", id, formatFieldChanges(diff)))
+		}
+		reportChanges("modify", before, tasks, messages)
+	},
+}
+
+// modification is what the change arguments of modify ask for.
+type modification struct {
+	fields     []fieldAssignment
+	addTags    []string
+	removeTags []string
+}
+
+type fieldAssignment struct {
+	name, value string
+}
+
+func (m modification) empty() bool {
+	return len(m.fields)+len(m.addTags)+len(m.removeTags) == 0
+}
+
+var idListArg = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+
+// splitModifyArgs separates the arguments that pick tasks from the
+// changes: the leading ID lists, or else just the first argument.
+func splitModifyArgs(args []string) (selection, changes []string) {
+	n := 0
+	for n < len(args) && idListArg.MatchString(args[n]) {
+		n++
+	}
+	if n == 0 {
+		n = 1
+	}
+	return args[:n], args[n:]
+}
+
+// parseChanges reads "field:value", "+tag" and "^tag" or "-tag" arguments.
+func parseChanges(args []string, cfg todo.Config) (modification, error) {
+	var m modification
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "+"), strings.HasPrefix(arg, "^"), strings.HasPrefix(arg, "-"):
+			tag, ok := todo.NormalizeTag(arg[1:])
+			if !ok {
+				return m, fmt.Errorf("invalid tag %q", arg[1:])
+			}
+			if arg[0] == '+' {
+				m.addTags = append(m.addTags, tag)
+			} else {
+				m.removeTags = append(m.removeTags, tag)
+			}
+		default:
+			name, value, ok := strings.Cut(arg, ":")
+			if !ok {
+				return m, fmt.Errorf("expected a change such as field:value or +tag, got %q", arg)
+			}
+			field, ok := todo.CanonicalField(cfg, name)
+			if !ok {
+				return m, fmt.Errorf("%q is not a field that can be changed", name)
+			}
+			m.fields = append(m.fields, fieldAssignment{field, value})
+		}
+	}
+	return m, nil
+}
+
+// formatFieldChanges describes changes as "priority M -> H, due (none) ->
+// 2025-04-10".
+func formatFieldChanges(changes []todo.FieldChange) string {
+	parts := []string{}
+	for _, change := range changes {
+		if change.Old == change.New {
+			parts = append(parts, change.Field+" changed")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s -> %s", change.Field, orNone(change.Old), orNone(change.New)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// normalizeTags checks and lower-cases tags given on the command line.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	for _, tag := range tags {
+		n, ok := todo.NormalizeTag(tag)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
+
+// setTaskFields applies "key=value" assignments to a task's custom fields.
+func setTaskFields(task *todo.Task, cfg todo.Config, assignments []string) error {
+	for _, assignment := range assignments {
+		name, value, err := todo.ParseAssignment(assignment)
+		if err != nil {
+			return err
+		}
+		if err := todo.SetField(task, cfg, name, value, time.Now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(modifyCmd)
+	modifyCmd.Flags().StringArrayVar(&setFlags, "set", nil, "Set a custom field (key=value, repeatable)")
+	modifyCmd.Flags().StringArrayVar(&unsetFlags, "unset", nil, "Clear a custom field (repeatable)")
+	modifyCmd.Flags().StringSliceVar(&addTagFlags, "add-tag", nil, "Add tags (repeatable or comma-separated)")
+	modifyCmd.Flags().StringSliceVar(&removeTagFlags, "remove-tag", nil, "Remove tags (repeatable or comma-separated)")
+	addBulkFlags(modifyCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var nextLimitFlag int
+
+var nextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Show the most urgent tasks that can be worked on now",
+	Long: `Show the most urgent tasks that can be worked on now.
+
+Tasks are ranked by urgency, a score built from priority, due date, age,
+tags, dependencies, scheduled and wait dates and running timers. The
+weight of each can be changed under "urgency" in config.json.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		ctx := todo.Context{Tasks: tasks, Config: cfg, Now: time.Now()}
+		ready := []todo.Task{}
+		for _, task := range tasks {
+			if todo.Ready(tasks, task, ctx.Now) {
+				ready = append(ready, task)
+			}
+		}
+		if len(ready) == 0 {
+			fmt.Println("Nothing to do")
+			return
+		}
+		if err := todo.SortTasks(ready, "urgency", ctx); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if nextLimitFlag > 0 && len(ready) > nextLimitFlag {
+			ready = ready[:nextLimitFlag]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "ID\tURGENCY\tPRI\tDESCRIPTION\tTAGS\tDUE")
+		for _, task := range ready {
+			fmt.Fprintf(w, "%d\t%.1f\t%s\t%s\t%s\t%s\n",
+				task.ID,
+				todo.Urgency(task, ctx),
+				task.Priority,
+				displayDescription(task, false),
+				strings.Join(task.Tags, " "),
+				todo.RelativeDue(task.Due, ctx.Now),
+			)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nextCmd)
+	nextCmd.Flags().IntVarP(&nextLimitFlag, "limit", "n", 5, "How many tasks to show")
+}
@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// outputFormats are the values accepted by list --output.
+var outputFormats = []string{"table", "json", "jsonl", "yaml", "csv", "markdown"}
+
+// taskView renders tasks for output. Secret descriptions stay redacted
+// unless reveal is set.
+type taskView struct {
+	ctx    todo.Context
+	reveal bool
+}
+
+// outputColumn is one field of the list output. display is what people
+// read in the table and Markdown formats; value is what scripts get from
+// the structured ones, with nil for unset fields.
+type outputColumn struct {
+	name    string
+	header  string
+	display func(v taskView, node todo.TreeNode) string
+	value   func(v taskView, t todo.Task) any
+}
+
+var builtinColumns = []outputColumn{
+	{"id", "ID",
+		func(v taskView, n todo.TreeNode) string { return strconv.Itoa(n.Task.ID) },
+		func(v taskView, t todo.Task) any { return t.ID }},
+	{"status", "STATUS",
+		func(v taskView, n todo.TreeNode) string {
+			if n.Task.Completed {
+				return "Complete"
+			}
+			return "Pending"
+		},
+		func(v taskView, t todo.Task) any {
+			if t.Completed {
+				return "completed"
+			}
+			return "pending"
+		}},
+	{"priority", "PRI",
+		func(v taskView, n todo.TreeNode) string { return n.Task.Priority },
+		func(v taskView, t todo.Task) any { return optionalString(t.Priority) }},
+	{"description", "DESCRIPTION",
+		func(v taskView, n todo.TreeNode) string {
+			description := displayDescription(n.Task, v.reveal)
+			if done, total := todo.Progress(v.ctx.Tasks, n.Task.ID); total > 0 {
+				description = fmt.Sprintf("%s [%d/%d]", description, done, total)
+			}
+			if n.Depth > 0 {
+				description = strings.Repeat("  ", n.Depth-1) + "└─ " + description
+			}
+			return description
+		},
+		func(v taskView, t todo.Task) any { return displayDescription(t, v.reveal) }},
+	{"tags", "TAGS",
+		func(v taskView, n todo.TreeNode) string { return strings.Join(n.Task.Tags, " ") },
+		func(v taskView, t todo.Task) any { return append([]string{}, t.Tags...) }},
+	{"created", "CREATED AT",
+		func(v taskView, n todo.TreeNode) string { return n.Task.CreatedAt.Format("2006-01-02 15:04:05") },
+		func(v taskView, t todo.Task) any { return optionalTime(t.CreatedAt) }},
+	{"completed", "COMPLETED AT",
+		func(v taskView, n todo.TreeNode) string {
+			return displayTime(n.Task.CompletedAt, "2006-01-02 15:04:05")
+		},
+		func(v taskView, t todo.Task) any { return optionalTime(t.CompletedAt) }},
+	{"due", "DUE",
+		func(v taskView, n todo.TreeNode) string {
+			if n.Task.Completed {
+				return displayTime(n.Task.Due, "2006-01-02")
+			}
+			return todo.RelativeDue(n.Task.Due, v.ctx.Now)
+		},
+		func(v taskView, t todo.Task) any { return optionalTime(t.Due) }},
+	{"wait", "WAIT",
+		func(v taskView, n todo.TreeNode) string { return displayTime(n.Task.Wait, "2006-01-02 15:04") },
+		func(v taskView, t todo.Task) any { return optionalTime(t.Wait) }},
+	{"scheduled", "SCHEDULED",
+		func(v taskView, n todo.TreeNode) string { return displayTime(n.Task.Scheduled, "2006-01-02 15:04") },
+		func(v taskView, t todo.Task) any { return optionalTime(t.Scheduled) }},
+	{"urgency", "URGENCY",
+		func(v taskView, n todo.TreeNode) string {
+			if n.Task.Completed {
+				return ""
+			}
+			return fmt.Sprintf("%.1f", todo.Urgency(n.Task, v.ctx))
+		},
+		func(v taskView, t todo.Task) any { return math.Round(todo.Urgency(t, v.ctx)*100) / 100 }},
+	{"parent", "PARENT",
+		func(v taskView, n todo.TreeNode) string {
+			if n.Task.ParentID == 0 {
+				return ""
+			}
+			return strconv.Itoa(n.Task.ParentID)
+		},
+		func(v taskView, t todo.Task) any {
+			if t.ParentID == 0 {
+				return nil
+			}
+			return t.ParentID
+		}},
+	{"depends", "DEPENDS",
+		func(v taskView, n todo.TreeNode) string { return joinInts(n.Task.DependsOn, ",") },
+		func(v taskView, t todo.Task) any { return append([]int{}, t.DependsOn...) }},
+	{"estimate", "ESTIMATE",
+		func(v taskView, n todo.TreeNode) string {
+			if n.Task.Estimate == 0 {
+				return ""
+			}
+			return formatDuration(n.Task.Estimate)
+		},
+		func(v taskView, t todo.Task) any {
+			if t.Estimate == 0 {
+				return nil
+			}
+			return formatDuration(t.Estimate)
+		}},
+	{"recur", "RECUR",
+		func(v taskView, n todo.TreeNode) string { return n.Task.Recur },
+		func(v taskView, t todo.Task) any { return optionalString(t.Recur) }},
+	{"secret", "SECRET",
+		func(v taskView, n todo.TreeNode) string {
+			if n.Task.Encrypted {
+				return "Yes"
+			}
+			return "No"
+		},
+		func(v taskView, t todo.Task) any { return t.Encrypted }},
+}
+
+// defaultTableColumns are shown by the table and Markdown formats, followed
+// by any custom fields. The structured formats default to every column.
+var defaultTableColumns = []string{"id", "status", "priority", "description", "tags", "created", "due", "secret"}
+
+// fieldColumn exposes a custom field. Numbers come out as JSON numbers.
+func fieldColumn(def todo.FieldDef) outputColumn {
+	return outputColumn{
+		name:    def.Name,
+		header:  strings.ToUpper(def.Name),
+		display: func(v taskView, n todo.TreeNode) string { return n.Task.Fields[def.Name] },
+		value: func(v taskView, t todo.Task) any {
+			value, ok := t.Fields[def.Name]
+			if !ok || value == "" {
+				return nil
+			}
+			if def.Type == "number" {
+				if n, err := strconv.ParseFloat(value, 64); err == nil {
+					return n
+				}
+			}
+			return value
+		},
+	}
+}
+
+// resolveColumns checks the output format and turns a --columns value into
+// columns. An empty spec picks the default set for the format.
+func resolveColumns(spec, format string, cfg todo.Config) ([]outputColumn, error) {
+	known := false
+	for _, f := range outputFormats {
+		known = known || f == format
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown output format %q (choose from %s)", format, strings.Join(outputFormats, ", "))
+	}
+
+	available := append([]outputColumn{}, builtinColumns...)
+	for _, def := range cfg.Fields {
+		available = append(available, fieldColumn(def))
+	}
+
+	names := []string{}
+	switch {
+	case spec != "":
+		for _, name := range strings.Split(spec, ",") {
+			names = append(names, strings.ToLower(strings.TrimSpace(name)))
+		}
+	case format == "table" || format == "markdown":
+		names = append(names, defaultTableColumns...)
+		for _, def := range cfg.Fields {
+			names = append(names, def.Name)
+		}
+	default:
+		return available, nil
+	}
+
+	columns := []outputColumn{}
+	for _, name := range names {
+		if name == "desc" {
+			name = "description"
+		}
+		found := false
+		for _, c := range available {
+			if c.name == name {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			choices := []string{}
+			for _, c := range available {
+				choices = append(choices, c.name)
+			}
+			return nil, fmt.Errorf("unknown column %q (choose from %s)", name, strings.Join(choices, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// renderTasks writes the tasks to w in the given format.
+func renderTasks(w io.Writer, format string, columns []outputColumn, nodes []todo.TreeNode, v taskView) error {
+	switch format {
+	case "table":
+		return renderTable(w, columns, nodes, v)
+	case "markdown":
+		return renderMarkdown(w, columns, nodes, v)
+	case "json", "jsonl":
+		return renderJSON(w, format == "jsonl", columns, nodes, v)
+	case "yaml":
+		return renderYAML(w, columns, nodes, v)
+	case "csv":
+		return renderCSV(w, columns, nodes, v)
+	}
+	return fmt.Errorf("unknown output format %q (choose from %s)", format, strings.Join(outputFormats, ", "))
+}
+
+func renderTable(w io.Writer, columns []outputColumn, nodes []todo.TreeNode, v taskView) error {
+	if len(nodes) == 0 {
+		_, err := fmt.Fprintln(w, "No tasks to display")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.Debug)
+	headers := []string{}
+	for _, c := range columns {
+		headers = append(headers, c.header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, node := range nodes {
+		cells := []string{}
+		for _, c := range columns {
+			cells = append(cells, c.display(v, node))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderMarkdown(w io.Writer, columns []outputColumn, nodes []todo.TreeNode, v taskView) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	headers, rules := []string{}, []string{}
+	for _, c := range columns {
+		headers = append(headers, c.header)
+		rules = append(rules, "---")
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(headers, " | "))
+	fmt.Fprintf(w, "| %s |\n", strings.Join(rules, " | "))
+	for _, node := range nodes {
+		cells := []string{}
+		for _, c := range columns {
+			cells = append(cells, escape.Replace(c.display(v, node)))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskObject encodes one task as a JSON object with its keys in column
+// order.
+func taskObject(columns []outputColumn, t todo.Task, v taskView) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, c := range columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(c.name)
+		value, err := json.Marshal(c.value(v, t))
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func renderJSON(w io.Writer, lines bool, columns []outputColumn, nodes []todo.TreeNode, v taskView) error {
+	objects := [][]byte{}
+	for _, node := range nodes {
+		object, err := taskObject(columns, node.Task, v)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, object)
+	}
+
+	if lines {
+		for _, object := range objects {
+			if _, err := fmt.Fprintf(w, "%s\n", object); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, append(append([]byte("["), bytes.Join(objects, []byte(","))...), ']'), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// renderYAML writes a YAML sequence of mappings. Values are written in
+// JSON syntax, which YAML reads as-is.
+func renderYAML(w io.Writer, columns []outputColumn, nodes []todo.TreeNode, v taskView) error {
+	if len(nodes) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	for _, node := range nodes {
+		for i, c := range columns {
+			value, err := json.Marshal(c.value(v, node.Task))
+			if err != nil {
+				return err
+			}
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+			if _, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, c.name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func renderCSV(w io.Writer, columns []outputColumn, nodes []todo.TreeNode, v taskView) error {
+	cw := csv.NewWriter(w)
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	cw.Write(names)
+	for _, node := range nodes {
+		record := []string{}
+		for _, c := range columns {
+			record = append(record, csvValue(c.value(v, node.Task)))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue flattens a structured value into a single CSV cell; lists are
+// space separated and unset values are empty.
+func csvValue(value any) string {
+	switch x := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case []string:
+		return strings.Join(x, " ")
+	case []int:
+		return joinInts(x, " ")
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func optionalString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optionalTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+func displayTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func joinInts(ids []int, sep string) string {
+	parts := []string{}
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// writePaged prints out, going through $PAGER (or less) when stdout is a
+// terminal and out has more lines than fit on it.
+func writePaged(out []byte, allowPager bool) {
+	if !allowPager || !stdoutIsTerminal() || bytes.Count(out, []byte("\n")) < terminalHeight() {
+		os.Stdout.Write(out)
+		return
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err != nil {
+			os.Stdout.Write(out)
+			return
+		}
+		pager = "less -FRX"
+	}
+	c := exec.Command("sh", "-c", pager)
+	c.Stdin = bytes.NewReader(out)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		os.Stdout.Write(out)
+	}
+}
+
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalHeight reports how many lines the terminal shows, from $LINES or
+// stty, falling back to 24.
+func terminalHeight() int {
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		return n
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return 24
+	}
+	defer tty.Close()
+	c := exec.Command("stty", "size")
+	c.Stdin = tty
+	size, err := c.Output()
+	if err != nil {
+		return 24
+	}
+	fields := strings.Fields(string(size))
+	if len(fields) == 2 {
+		if n, err := strconv.Atoi(fields[0]); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 24
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var recurCmd = &cobra.Command{
+	Use:   "recur",
+	Short: "Manage recurring task series",
+}
+
+var recurPauseCmd = &cobra.Command{
+	Use:   "pause [task ID]",
+	Short: "Stop spawning new occurrences until resumed",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateSeries("recur pause", args[0], func(template *todo.Task) error {
+			template.RecurPaused = true
+			fmt.Printf("Series %d paused\n", template.ID)
+			return nil
+		})
+	},
+}
+
+var recurResumeCmd = &cobra.Command{
+	Use:   "resume [task ID]",
+	Short: "Resume a paused series",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateSeries("recur resume", args[0], func(template *todo.Task) error {
+			template.RecurPaused = false
+			fmt.Printf("Series %d resumed\n", template.ID)
+			return nil
+		})
+	},
+}
+
+var recurEditCmd = &cobra.Command{
+	Use:   "edit [task ID] [rule]",
+	Short: "Change the recurrence rule of a series",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateSeries("recur edit", args[0], func(template *todo.Task) error {
+			rule, err := todo.ParseRecurrence(strings.Join(args[1:], " "))
+			if err != nil {
+				return err
+			}
+			anchor := template.Due
+			if anchor.IsZero() {
+				anchor = template.CreatedAt
+			}
+			template.Recur = rule.Anchor(anchor).String()
+			fmt.Printf("Series %d now repeats %s\n", template.ID, template.Recur)
+			return nil
+		})
+	},
+}
+
+var recurEndCmd = &cobra.Command{
+	Use:   "end [task ID]",
+	Short: "End a series so no further occurrences are spawned",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateSeries("recur end", args[0], func(template *todo.Task) error {
+			rule, err := todo.ParseRecurrence(template.Recur)
+			if err != nil {
+				return err
+			}
+			rule.Until = time.Now().UTC().Truncate(time.Second)
+			template.Recur = rule.String()
+			fmt.Printf("Series %d ended\n", template.ID)
+			return nil
+		})
+	},
+}
+
+// updateSeries loads the tasks, applies change to the template of the series
+// containing the given task and saves the result, recording it in the
+// history as command.
+func updateSeries(command, arg string, change func(template *todo.Task) error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		fmt.Println("Error converting task ID to int:", err)
+		return
+	}
+	tasks, err := todo.LoadTasks("tasks.csv")
+	if err != nil {
+		fmt.Println("Error loading tasks:", err)
+		return
+	}
+	before := todo.CloneTasks(tasks)
+	idx, err := todo.SeriesTemplate(tasks, id)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if err := change(&tasks[idx]); err != nil {
+		fmt.Println("Error updating series:", err)
+		return
+	}
+	if err := saveTasks(command, before, tasks); err != nil {
+		fmt.Println("Error saving tasks:", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(recurCmd)
+	recurCmd.AddCommand(recurPauseCmd, recurResumeCmd, recurEditCmd, recurEndCmd)
+}
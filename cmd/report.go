@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var weekFlag bool
+var sinceFlag string
+var svgFlag string
+var asciiFlag bool
+var chartHeightFlag int
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Reports on how your tasks are going",
+}
+
+var reportTimeCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Time tracked per task and per tag, compared to estimates",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+
+		now := time.Now().In(cfg.Location())
+		from := time.Time{}
+		if weekFlag {
+			from = todo.StartOfWeekOn(now, cfg.FirstWeekday())
+		}
+		byTask, byTag := todo.TimeReport(tasks, from, time.Time{}, now)
+		if len(byTask) == 0 {
+			fmt.Println("No time tracked")
+			return
+		}
+
+		for i := range byTask {
+			for _, task := range tasks {
+				if task.ID == byTask[i].TaskID {
+					byTask[i].Name = displayDescription(task, false)
+				}
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "ID\tTASK\tSPENT\tESTIMATE\tREMAINING")
+		for _, entry := range byTask {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", entry.TaskID, entry.Name, timeColumns(entry))
+		}
+		w.Flush()
+
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "TAG\tSPENT\tESTIMATE\tREMAINING")
+		for _, entry := range byTag {
+			fmt.Fprintf(w, "%s\t%s\n", entry.Name, timeColumns(entry))
+		}
+		w.Flush()
+	},
+}
+
+var reportBurndownCmd = &cobra.Command{
+	Use:   "burndown",
+	Short: "Chart how many tasks were open each day",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		showFlowChart(func(points []todo.FlowPoint) chart {
+			open := []float64{}
+			for _, point := range points {
+				open = append(open, float64(point.Open))
+			}
+			first, last := points[0].Open, points[len(points)-1].Open
+			return chart{
+				title:  fmt.Sprintf("Open tasks: %d -> %d", first, last),
+				layers: []chartLayer{{name: "open", values: open, glyph: "█", ascii: "#", color: "#4a7bd0"}},
+			}
+		})
+	},
+}
+
+var reportCFDCmd = &cobra.Command{
+	Use:   "cfd",
+	Short: "Chart completed and open tasks over time (cumulative flow)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		showFlowChart(func(points []todo.FlowPoint) chart {
+			completed, open := []float64{}, []float64{}
+			for _, point := range points {
+				completed = append(completed, float64(point.Completed))
+				open = append(open, float64(point.Open))
+			}
+			last := points[len(points)-1]
+			return chart{
+				title: fmt.Sprintf("Cumulative flow: %d created, %d completed, %d open", last.Created, last.Completed, last.Open),
+				layers: []chartLayer{
+					{name: "completed", values: completed, glyph: "█", ascii: "#", color: "#5aa65a"},
+					{name: "open", values: open, glyph: "░", ascii: ".", color: "#e0a040"},
+				},
+			}
+		})
+	},
+}
+
+// showFlowChart draws a chart of the tasks from --since until today in
+// the terminal, or writes it to the --svg file.
+func showFlowChart(build func([]todo.FlowPoint) chart) {
+	now := time.Now()
+	since, err := todo.ParseSince(sinceFlag, func() time.Time { return now })
+	if err != nil {
+		fmt.Println("Error parsing --since:", err)
+		return
+	}
+	if since.After(now) {
+		fmt.Println("--since must be in the past")
+		return
+	}
+	if chartHeightFlag < 2 {
+		fmt.Println("--height must be at least 2")
+		return
+	}
+	tasks, err := todo.LoadTasks("tasks.csv")
+	if err != nil {
+		fmt.Println("Error loading tasks:", err)
+		return
+	}
+
+	points := todo.Flow(tasks, since, now)
+	c := build(points)
+	for _, point := range points {
+		c.days = append(c.days, point.Day)
+	}
+
+	if svgFlag != "" {
+		var out bytes.Buffer
+		c.svg(&out)
+		if err := os.WriteFile(svgFlag, out.Bytes(), 0644); err != nil {
+			fmt.Println("Error writing SVG:", err)
+			return
+		}
+		fmt.Printf("Chart written to %s\n", svgFlag)
+		return
+	}
+	c.sample(chartWidth()).render(os.Stdout, chartHeightFlag, asciiFlag)
+}
+
+// chartWidth is how many day columns fit in the terminal, from $COLUMNS
+// or 80 characters.
+func chartWidth() int {
+	columns, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || columns <= 0 {
+		columns = 80
+	}
+	return max(10, columns-8)
+}
+
+// timeColumns renders the spent, estimate and remaining cells of a report
+// line. Remaining goes negative once the estimate is blown.
+func timeColumns(entry todo.TimeEntry) string {
+	if entry.Estimate == 0 {
+		return formatDuration(entry.Spent) + "\t\t"
+	}
+	remaining := entry.Estimate - entry.Spent
+	sign := ""
+	if remaining < 0 {
+		sign = "-"
+		remaining = -remaining
+	}
+	return fmt.Sprintf("%s\t%s\t%s%s", formatDuration(entry.Spent), formatDuration(entry.Estimate), sign, formatDuration(remaining))
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportTimeCmd)
+	reportTimeCmd.Flags().BoolVar(&weekFlag, "week", false, "Only count time tracked since the start of the week (see week_start in config.json)")
+	for _, c := range []*cobra.Command{reportBurndownCmd, reportCFDCmd} {
+		reportCmd.AddCommand(c)
+		c.Flags().StringVar(&sinceFlag, "since", "30d", "Start of the chart: a span back from today (30d, 6w, 3m) or a date")
+		c.Flags().StringVar(&svgFlag, "svg", "", "Write the chart to this SVG file instead")
+		c.Flags().BoolVar(&asciiFlag, "ascii", false, "Draw with plain ASCII characters")
+		c.Flags().IntVar(&chartHeightFlag, "height", 12, "Chart height in lines")
+	}
+}
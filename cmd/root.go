@@ -1,6 +1,9 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "R2-D2",
@@ -8,5 +11,23 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() error {
+	// The REPL runs many commands through the same rootCmd, so flags must
+	// not leak from one line into the next.
+	defer resetFlags(rootCmd)
 	return rootCmd.Execute()
 }
+
+// resetFlags puts every flag of c and its subcommands back to its default.
+func resetFlags(c *cobra.Command) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
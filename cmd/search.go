@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+var searchSecretsFlag bool
+var searchLimitFlag int
+
+var searchCmd = &cobra.Command{
+	Use:   "search [terms]",
+	Short: "Search task descriptions, notes and tags",
+	Long: `Search task descriptions, notes and tags, best matches first.
+
+Words also match longer words they start ("deploy" finds "deployment") and
+words with a typo or two ("paymnet" finds "payment"). Matches are
+highlighted when printing to a terminal, unless NO_COLOR is set.
+
+The search index is kept in tasks.csv.idx and never holds secret tasks;
+-d searches them too, decrypting them in memory only.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		idx, err := todo.OpenIndex("tasks.csv", tasks)
+		if err != nil {
+			fmt.Println("Error opening search index:", err)
+			return
+		}
+
+		byID := map[int]todo.Task{}
+		for _, task := range tasks {
+			if task.Encrypted && searchSecretsFlag {
+				task = decryptTask(task)
+				idx.AddPrivate(task.ID, searchTexts(task)...)
+			}
+			byID[task.ID] = task
+		}
+
+		results := idx.Search(strings.Join(args, " "))
+		if len(results) == 0 {
+			fmt.Println("No tasks match")
+			return
+		}
+		if searchLimitFlag > 0 && len(results) > searchLimitFlag {
+			results = results[:searchLimitFlag]
+		}
+
+		color := stdoutIsTerminal() && os.Getenv("NO_COLOR") == ""
+		for _, result := range results {
+			task := byID[result.ID]
+			matched := map[string]bool{}
+			for _, term := range result.Matched {
+				matched[term] = true
+			}
+			status := " "
+			if task.Completed {
+				status = "✓"
+			}
+			line := fmt.Sprintf("%3d %s %s", task.ID, status, highlightTerms(task.Description, matched, color))
+			for _, tag := range task.Tags {
+				line += " +" + highlightTerms(tag, matched, color)
+			}
+			fmt.Printf("%s  (%.2f)\n", line, result.Score)
+			for _, note := range task.Notes {
+				if containsTerm(note.Text, matched) {
+					fmt.Printf("      %s\n", highlightTerms(note.Text, matched, color))
+				}
+			}
+		}
+	},
+}
+
+// decryptTask returns a copy of a secret task with its description and
+// notes in plain text. Parts that fail to decrypt are left as they are.
+func decryptTask(task todo.Task) todo.Task {
+	if text, err := todo.DecryptText(task.Description); err == nil {
+		task.Description = text
+	}
+	notes := make([]todo.Note, len(task.Notes))
+	for i, note := range task.Notes {
+		if text, err := todo.DecryptText(note.Text); err == nil {
+			note.Text = text
+		}
+		notes[i] = note
+	}
+	task.Notes = notes
+	return task
+}
+
+func searchTexts(task todo.Task) []string {
+	texts := append([]string{task.Description}, task.Tags...)
+	for _, note := range task.Notes {
+		texts = append(texts, note.Text)
+	}
+	return texts
+}
+
+// highlightTerms marks the words of text found in terms, in bold yellow
+// when color is set and between asterisks otherwise.
+func highlightTerms(text string, terms map[string]bool, color bool) string {
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		switch {
+		case !terms[strings.ToLower(string(word))]:
+			b.WriteString(string(word))
+		case color:
+			b.WriteString("\x1b[1;33m" + string(word) + "\x1b[0m")
+		default:
+			b.WriteString("*" + string(word) + "*")
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+func containsTerm(text string, terms map[string]bool) bool {
+	for _, term := range todo.SearchTerms(text) {
+		if terms[term] {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().BoolVarP(&searchSecretsFlag, "show-secrets", "d", false, "Also search secret tasks")
+	searchCmd.Flags().IntVarP(&searchLimitFlag, "limit", "n", 10, "Show at most this many results")
+}
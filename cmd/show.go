@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var showDecryptFlag bool
+
+var showCmd = &cobra.Command{
+	Use:   "show [task ID]",
+	Short: "Show every detail of a task, including its notes",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error converting task ID to int:", err)
+			return
+		}
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		for _, task := range tasks {
+			if task.ID == id {
+				printTaskDetails(task, todo.Context{Tasks: tasks, Config: cfg, Now: time.Now()})
+				return
+			}
+		}
+		fmt.Printf("Task %d not found\n", id)
+	},
+}
+
+// printTaskDetails writes the detail view of a task: one labelled line per
+// field that is set, followed by its notes.
+func printTaskDetails(task todo.Task, ctx todo.Context) {
+	tasks, now := ctx.Tasks, ctx.Now
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s\t%s\n", label, value)
+		}
+	}
+	timestamp := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	status := "Pending"
+	if task.Completed {
+		status = "Complete"
+	}
+
+	field("ID", strconv.Itoa(task.ID))
+	field("Description", displayDescription(task, showDecryptFlag))
+	field("Status", status)
+	if task.Encrypted {
+		field("Secret", "Yes")
+	}
+	field("Priority", task.Priority)
+	if !task.Completed {
+		field("Urgency", fmt.Sprintf("%.1f", todo.Urgency(task, ctx)))
+	}
+	field("Tags", strings.Join(task.Tags, " "))
+	names := []string{}
+	for name := range task.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field(name, task.Fields[name])
+	}
+	field("Created", timestamp(task.CreatedAt))
+	field("Completed", timestamp(task.CompletedAt))
+	if !task.Due.IsZero() {
+		field("Due", fmt.Sprintf("%s (%s)", timestamp(task.Due), todo.RelativeDue(task.Due, now)))
+	}
+	field("Wait", timestamp(task.Wait))
+	field("Scheduled", timestamp(task.Scheduled))
+	if task.Estimate != 0 {
+		field("Estimate", formatDuration(task.Estimate))
+	}
+	if spent := todo.TimeSpent(task, time.Time{}, time.Time{}, now); spent > 0 {
+		if n := len(task.TimeLog); task.TimeLog[n-1].End.IsZero() {
+			field("Time spent", formatDuration(spent)+" (timer running)")
+		} else {
+			field("Time spent", formatDuration(spent))
+		}
+	}
+	if task.ParentID != 0 {
+		field("Parent", strconv.Itoa(task.ParentID))
+	}
+	if done, total := todo.Progress(tasks, task.ID); total > 0 {
+		field("Subtasks", fmt.Sprintf("%d/%d done", done, total))
+	}
+	if len(task.DependsOn) > 0 {
+		ids := []string{}
+		for _, dep := range task.DependsOn {
+			ids = append(ids, strconv.Itoa(dep))
+		}
+		field("Depends on", strings.Join(ids, ", "))
+	}
+	if task.Recur != "" {
+		recur := task.Recur
+		if task.RecurPaused {
+			recur += " (paused)"
+		}
+		field("Recurrence", recur)
+	}
+	if task.RecurParent != 0 {
+		field("Series", strconv.Itoa(task.RecurParent))
+	}
+	w.Flush()
+
+	if len(task.Notes) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Notes:")
+	for _, note := range task.Notes {
+		fmt.Printf("  %s  %s\n", timestamp(note.At), displayNote(task, note, showDecryptFlag))
+	}
+}
+
+// displayNote returns a note's text, redacted on secret tasks unless reveal
+// is set.
+func displayNote(task todo.Task, note todo.Note, reveal bool) string {
+	return displayDescription(todo.Task{Description: note.Text, Encrypted: task.Encrypted}, reveal)
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	showCmd.Flags().BoolVarP(&showDecryptFlag, "show-secrets", "d", false, "Decrypt the description and notes of a secret task")
+}
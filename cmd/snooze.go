@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze [task ID] [when]",
+	Short: "Hide a task from list until a later date",
+	Long: `Hide a task from list until a later date, e.g. "snooze 4 3d" or
+"snooze 4 next mon". The task comes back on its own once the date passes;
+"snooze 4 now" brings it back straight away.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error converting task ID to int:", err)
+			return
+		}
+		until, err := todo.ParseDate(strings.Join(args[1:], " "), time.Now)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		before := todo.CloneTasks(tasks)
+		for i, task := range tasks {
+			if task.ID != id {
+				continue
+			}
+			if task.Completed {
+				fmt.Printf("Task %d is already completed; only pending tasks can be snoozed\n", id)
+				return
+			}
+			tasks[i].Wait = until
+			if err := saveTasks("snooze", before, tasks); err != nil {
+				fmt.Println("Error saving tasks:", err)
+				return
+			}
+			if tasks[i].Waiting(time.Now()) {
+				fmt.Printf("Task %d snoozed until %s\n", id, until.Format("2006-01-02 15:04"))
+			} else {
+				fmt.Printf("Task %d is no longer snoozed\n", id)
+			}
+			return
+		}
+		fmt.Printf("Task %d not found\n", id)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snoozeCmd)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var statsOutputFlag string
+var statsWeeksFlag int
+var statsOldestFlag int
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show counts, lead times and completions per week",
+	Long: `Show how many tasks are open and completed, how long tasks take from
+being added to being completed (lead time), how many are created and
+completed each week, the oldest open tasks and the same per tag. Weeks
+start on the config's "week_start" day, in its "timezone".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(statsOutputFlag)
+		if format != "table" && format != "json" {
+			fmt.Printf("Unknown output format %q: use table or json\n", statsOutputFlag)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		now := time.Now().In(cfg.Location())
+		stats := todo.ComputeStats(tasks, now, cfg.FirstWeekday(), max(statsWeeksFlag, 0), max(statsOldestFlag, 0))
+		if format == "json" {
+			data, err := json.MarshalIndent(newStatsJSON(stats, now), "", "  ")
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+		printStats(stats, now)
+	},
+}
+
+func printStats(s todo.Stats, now time.Time) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Open\t%d\n", s.Open)
+	fmt.Fprintf(w, "Completed\t%d\n", s.Completed)
+	if s.Completed > 0 {
+		fmt.Fprintf(w, "Lead time\tmean %s, median %s\n", formatLeadTime(s.MeanLead), formatLeadTime(s.MedianLead))
+	}
+	if len(s.Weeks) > 0 {
+		fmt.Fprintf(w, "Completed per week\t%.1f over the last %d weeks\n", s.PerWeekMean, len(s.Weeks))
+	}
+	w.Flush()
+
+	if len(s.Weeks) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "WEEK OF\tCREATED\tCOMPLETED")
+		for _, week := range s.Weeks {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", week.Start.Format("Jan 2, 2006"), week.Created, week.Completed)
+		}
+		w.Flush()
+	}
+
+	if len(s.Oldest) > 0 {
+		fmt.Println()
+		fmt.Println("Oldest open tasks")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "ID\tAGE\tDESCRIPTION")
+		for _, task := range s.Oldest {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", task.ID, formatLeadTime(now.Sub(task.CreatedAt)), displayDescription(task, false))
+		}
+		w.Flush()
+	}
+
+	if len(s.Tags) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "TAG\tOPEN\tCOMPLETED\tMEDIAN LEAD TIME")
+		for _, tag := range s.Tags {
+			lead := ""
+			if tag.Completed > 0 {
+				lead = formatLeadTime(tag.MedianLead)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", tag.Tag, tag.Open, tag.Completed, lead)
+		}
+		w.Flush()
+	}
+}
+
+// formatLeadTime renders long durations in days and hours, e.g. "3d 4h",
+// and shorter ones like the time report does.
+func formatLeadTime(d time.Duration) string {
+	if d < 24*time.Hour {
+		return formatDuration(d)
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if hours == 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
+
+// statsJSON is the shape of "stats -o json". Lead times are in hours.
+type statsJSON struct {
+	Open             int            `json:"open"`
+	Completed        int            `json:"completed"`
+	MeanLeadHours    float64        `json:"mean_lead_time_hours"`
+	MedianLeadHours  float64        `json:"median_lead_time_hours"`
+	CompletedPerWeek float64        `json:"completed_per_week"`
+	Weeks            []weekJSON     `json:"weeks"`
+	Oldest           []oldestJSON   `json:"oldest_open"`
+	Tags             []tagStatsJSON `json:"tags"`
+}
+
+type weekJSON struct {
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+type oldestJSON struct {
+	ID          int     `json:"id"`
+	Description string  `json:"description"`
+	CreatedAt   string  `json:"created"`
+	AgeHours    float64 `json:"age_hours"`
+}
+
+type tagStatsJSON struct {
+	Tag             string  `json:"tag"`
+	Open            int     `json:"open"`
+	Completed       int     `json:"completed"`
+	MedianLeadHours float64 `json:"median_lead_time_hours"`
+}
+
+func newStatsJSON(s todo.Stats, now time.Time) statsJSON {
+	out := statsJSON{
+		Open:             s.Open,
+		Completed:        s.Completed,
+		MeanLeadHours:    hours(s.MeanLead),
+		MedianLeadHours:  hours(s.MedianLead),
+		CompletedPerWeek: s.PerWeekMean,
+		Weeks:            []weekJSON{},
+		Oldest:           []oldestJSON{},
+		Tags:             []tagStatsJSON{},
+	}
+	for _, week := range s.Weeks {
+		out.Weeks = append(out.Weeks, weekJSON{week.Start.Format("2006-01-02"), week.Created, week.Completed})
+	}
+	for _, task := range s.Oldest {
+		out.Oldest = append(out.Oldest, oldestJSON{
+			ID:          task.ID,
+			Description: displayDescription(task, false),
+			CreatedAt:   task.CreatedAt.Format(time.RFC3339),
+			AgeHours:    hours(now.Sub(task.CreatedAt)),
+		})
+	}
+	for _, tag := range s.Tags {
+		out.Tags = append(out.Tags, tagStatsJSON{tag.Tag, tag.Open, tag.Completed, hours(tag.MedianLead)})
+	}
+	return out
+}
+
+// hours converts a duration to hours rounded to one decimal.
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*10) / 10
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().StringVarP(&statsOutputFlag, "output", "o", "table", "Output format: table or json")
+	statsCmd.Flags().IntVar(&statsWeeksFlag, "weeks", 8, "Number of weeks to count completions for")
+	statsCmd.Flags().IntVar(&statsOldestFlag, "oldest", 5, "Number of oldest open tasks to show")
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var statusTemplateFlag string
+var statusRevealFlag bool
+
+// statusData is what a status template sees as ".".
+type statusData struct {
+	Pending   int
+	Completed int
+	Overdue   int
+	DueToday  int
+	Waiting   int
+	Ready     int
+	Blocked   int
+	Next      *taskData  // the most urgent ready task, if any
+	Active    *taskData  // the task with a running timer, if any
+	Tasks     []taskData // open tasks, most urgent first
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print a one-line summary of your tasks",
+	Long: `Print a one-line summary of your tasks, for shell prompts and status bars.
+
+--template takes a Go template, or the name of one in config.json, and
+gets Pending, Completed, Overdue, DueToday, Waiting, Ready and Blocked
+counts, Next (the most urgent ready task), Active (the task with a running
+timer) and Tasks (open tasks, most urgent first). Next and Active are
+tasks as described in "list --help" and are empty when there is none. The
+same helpers are available:
+
+  R2-D2 status --template '{{.Pending}}{{with .Next}} {{.Description | truncate 20 | color "yellow"}}{{end}}'`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		now := time.Now()
+		text := statusTemplateFlag
+		if text == "" {
+			text = defaultStatusTemplate
+		}
+		tmpl, err := parseTemplate(text, cfg, now)
+		if err != nil {
+			fmt.Println("Error in template:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		view := taskView{ctx: todo.Context{Tasks: tasks, Config: cfg, Now: now}, reveal: statusRevealFlag}
+		if err := tmpl.Execute(os.Stdout, summarize(tasks, view)); err != nil {
+			fmt.Println("\nError in template:", err)
+			return
+		}
+		fmt.Println()
+	},
+}
+
+const defaultStatusTemplate = `{{.Pending}} pending` +
+	`{{if or .Overdue .DueToday}} ({{.Overdue}} overdue, {{.DueToday}} due today){{end}}` +
+	`{{with .Active}} | active: {{.ID}} {{.Description | truncate 30}} ({{duration .Spent}}){{end}}` +
+	`{{with .Next}} | next: {{.ID}} {{.Description | truncate 30}}{{end}}`
+
+// summarize counts tasks by state and picks out the next and active ones.
+func summarize(tasks []todo.Task, v taskView) statusData {
+	now := v.ctx.Now
+	data := statusData{Tasks: []taskData{}}
+	open := []todo.Task{}
+	for _, task := range tasks {
+		if task.Completed {
+			data.Completed++
+			continue
+		}
+		open = append(open, task)
+		data.Pending++
+		if task.Overdue(now) {
+			data.Overdue++
+		} else if !task.Due.IsZero() && sameDay(task.Due, now) {
+			data.DueToday++
+		}
+		if task.Waiting(now) {
+			data.Waiting++
+		}
+		if todo.Blocked(tasks, task) {
+			data.Blocked++
+		}
+	}
+
+	todo.SortTasks(open, "urgency", v.ctx)
+	for _, task := range open {
+		data.Tasks = append(data.Tasks, newTaskData(task, v))
+		if todo.Ready(tasks, task, now) {
+			data.Ready++
+			if data.Next == nil {
+				next := newTaskData(task, v)
+				data.Next = &next
+			}
+		}
+	}
+	if i := todo.ActiveTimer(tasks); i >= 0 {
+		active := newTaskData(tasks[i], v)
+		data.Active = &active
+	}
+	return data
+}
+
+// sameDay reports whether a falls on b's calendar day in b's location.
+func sameDay(a, b time.Time) bool {
+	a = a.In(b.Location())
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringVar(&statusTemplateFlag, "template", "", "Go template, or the name of a template in the config")
+	statusCmd.Flags().BoolVarP(&statusRevealFlag, "show-secrets", "d", false, "Decrypt secret task descriptions")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var syncMdCmd = &cobra.Command{
+	Use:   "sync-md FILE [filter]",
+	Short: "Keep a Markdown checklist and the tasks in step",
+	Long: `Reconcile a Markdown checklist, such as a repository's TODO.md, with the
+tasks in both directions. Checking a box completes the task and completing
+the task checks the box; edits to an item's text, +tags included, go the
+same way. When both sides changed the text, the task's text wins. As with
+"complete", a task with open subtasks is not completed: its box is
+unchecked again unless the same sync checks all of its subtasks.
+
+Items are matched to tasks by a hidden comment at the end of the line with
+the task's ID and creation time, so a new task that reuses the ID of a
+deleted one is not mistaken for it. Items without a comment are added as
+new tasks, items whose task was deleted are removed, and tasks matching the filter that are not in the file yet are
+added after its last item. Anything else in the file is left alone.
+
+The filter defaults to "status:pending" and is remembered at the top of the
+file, so later syncs need only the file name. A task taken out of the file
+comes back while it still matches; complete or delete it instead. Secret
+tasks are never written to the file.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := args[0]
+		cfg, err := todo.LoadConfig("config.json")
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		data, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Println("Error reading file:", err)
+			return
+		}
+		text := string(data)
+
+		query := todo.DefaultSyncFilter
+		if len(args) > 1 {
+			query = filterQuery(args[1:])
+		} else if recorded, ok := todo.MarkdownFilter(text); ok {
+			query = recorded
+		}
+		filter, ok := parseFilterArgs([]string{query}, cfg)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		ctx := todo.Context{Tasks: tasks, Config: cfg, Now: now}
+		include := func(t todo.Task) bool { return filter.Match(t, ctx) }
+		synced, updated, changes, err := todo.SyncMarkdown(text, tasks, include, now)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		synced = todo.SetMarkdownFilter(synced, query)
+
+		// The store goes first: should writing the file fail, the next
+		// sync still sees which side changed
+		storeChanged := false
+		for _, change := range changes {
+			storeChanged = storeChanged || !change.InFile
+		}
+		if storeChanged {
+			if err := saveTasks("sync-md", tasks, updated); err != nil {
+				fmt.Println("Error saving tasks:", err)
+				return
+			}
+		}
+		if synced != text {
+			if err := os.WriteFile(file, []byte(synced), 0644); err != nil {
+				fmt.Println("Error writing file:", err)
+				return
+			}
+		}
+
+		if len(changes) == 0 {
+			fmt.Printf("%s is in sync\n", file)
+		}
+		for _, change := range changes {
+			fmt.Println(describeSyncChange(change, file))
+		}
+	},
+}
+
+func describeSyncChange(c todo.SyncChange, file string) string {
+	if !c.InFile {
+		switch c.What {
+		case "added":
+			return fmt.Sprintf("Task %d added from %s", c.ID, file)
+		case "completed":
+			return fmt.Sprintf("Task %d completed (checked in %s)", c.ID, file)
+		case "reopened":
+			return fmt.Sprintf("Task %d reopened (unchecked in %s)", c.ID, file)
+		}
+		return fmt.Sprintf("Task %d updated from %s", c.ID, file)
+	}
+	switch c.What {
+	case "added":
+		return fmt.Sprintf("%s: added task %d", file, c.ID)
+	case "completed":
+		return fmt.Sprintf("%s: checked task %d", file, c.ID)
+	case "reopened":
+		return fmt.Sprintf("%s: unchecked task %d", file, c.ID)
+	case "removed":
+		return fmt.Sprintf("%s: removed task %d, which was deleted", file, c.ID)
+	case "conflict":
+		return fmt.Sprintf("%s: task %d changed in both places; kept the task's text", file, c.ID)
+	case "refused":
+		return fmt.Sprintf("%s: unchecked task %d; it has open subtasks (complete them first)", file, c.ID)
+	}
+	return fmt.Sprintf("%s: updated task %d", file, c.ID)
+}
+
+func init() {
+	rootCmd.AddCommand(syncMdCmd)
+}
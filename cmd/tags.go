@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Show tags and how many tasks use them",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+
+		counts := todo.CountTags(tasks)
+		if len(counts) == 0 {
+			fmt.Println("No tags in use")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "TAG\tPENDING\tTOTAL")
+		for _, c := range counts {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", c.Tag, c.Pending, c.Total)
+		}
+		w.Flush()
+	},
+}
+
+var tagsRenameCmd = &cobra.Command{
+	Use:   "rename [old] [new]",
+	Short: "Rename a tag on every task that carries it",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		retag("tags rename", args[1], args[:1])
+	},
+}
+
+var tagsMergeCmd = &cobra.Command{
+	Use:   "merge [target] [source...]",
+	Short: "Fold one or more tags into a target tag",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		retag("tags merge", args[0], args[1:])
+	},
+}
+
+// retag rewrites every task tagged with one of from to use to instead,
+// recording it in the history as command.
+func retag(command, to string, from []string) {
+	target, ok := todo.NormalizeTag(to)
+	if !ok {
+		fmt.Printf("Invalid tag %q\n", to)
+		return
+	}
+	sources := []string{}
+	for _, tag := range from {
+		normalized, ok := todo.NormalizeTag(tag)
+		if !ok {
+			fmt.Printf("Invalid tag %q\n", tag)
+			return
+		}
+		sources = append(sources, normalized)
+	}
+
+	tasks, err := todo.LoadTasks("tasks.csv")
+	if err != nil {
+		fmt.Println("Error loading tasks:", err)
+		return
+	}
+	before := todo.CloneTasks(tasks)
+	changed := todo.RenameTag(tasks, target, sources...)
+	if changed == 0 {
+		fmt.Println("No tasks to update")
+		return
+	}
+	if err := saveTasks(command, before, tasks); err != nil {
+		fmt.Println("Error saving tasks:", err)
+		return
+	}
+	fmt.Printf("Retagged %d task(s) as %s\n", changed, target)
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+	tagsCmd.AddCommand(tagsRenameCmd, tagsMergeCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// taskData is what a task template sees as "." — see "list --help".
+type taskData struct {
+	ID          int
+	Description string // redacted for secret tasks unless revealed
+	Status      string // pending or completed
+	Completed   bool
+	Overdue     bool
+	Priority    string
+	Tags        []string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	Due         time.Time
+	Wait        time.Time
+	Scheduled   time.Time
+	Urgency     float64
+	ParentID    int
+	DependsOn   []int
+	Estimate    time.Duration
+	Spent       time.Duration
+	Recur       string
+	Secret      bool
+	Fields      map[string]string
+}
+
+func newTaskData(t todo.Task, v taskView) taskData {
+	status := "pending"
+	if t.Completed {
+		status = "completed"
+	}
+	fields := map[string]string{}
+	for name, value := range t.Fields {
+		fields[name] = value
+	}
+	return taskData{
+		ID:          t.ID,
+		Description: displayDescription(t, v.reveal),
+		Status:      status,
+		Completed:   t.Completed,
+		Overdue:     t.Overdue(v.ctx.Now),
+		Priority:    t.Priority,
+		Tags:        append([]string{}, t.Tags...),
+		CreatedAt:   t.CreatedAt,
+		CompletedAt: t.CompletedAt,
+		Due:         t.Due,
+		Wait:        t.Wait,
+		Scheduled:   t.Scheduled,
+		Urgency:     todo.Urgency(t, v.ctx),
+		ParentID:    t.ParentID,
+		DependsOn:   append([]int{}, t.DependsOn...),
+		Estimate:    t.Estimate,
+		Spent:       todo.TimeSpent(t, time.Time{}, time.Time{}, v.ctx.Now),
+		Recur:       t.Recur,
+		Secret:      t.Encrypted,
+		Fields:      fields,
+	}
+}
+
+var ansiColors = map[string]string{
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"gray": "90", "grey": "90", "bold": "1", "dim": "2", "underline": "4",
+}
+
+// templateFuncs are the helpers available in every template. Colours are
+// dropped when the NO_COLOR environment variable is set.
+func templateFuncs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"relative": func(t time.Time) string { return todo.RelativeDue(t, now) },
+		"ago":      func(t time.Time) string { return formatAgo(now.Sub(t)) },
+		"date": func(layout string, t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(layout)
+		},
+		"duration": formatDuration,
+		"color": func(name string, s any) (string, error) {
+			code, ok := ansiColors[strings.ToLower(name)]
+			if !ok {
+				return "", fmt.Errorf("unknown colour %q", name)
+			}
+			if os.Getenv("NO_COLOR") != "" {
+				return fmt.Sprint(s), nil
+			}
+			return "\x1b[" + code + "m" + fmt.Sprint(s) + "\x1b[0m", nil
+		},
+		"pad": func(width int, s any) string {
+			text := fmt.Sprint(s)
+			return text + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(text)))
+		},
+		"padLeft": func(width int, s any) string {
+			text := fmt.Sprint(s)
+			return strings.Repeat(" ", max(0, width-utf8.RuneCountInString(text))) + text
+		},
+		"truncate": func(width int, s any) string {
+			text := []rune(fmt.Sprint(s))
+			if len(text) <= width {
+				return string(text)
+			}
+			if width < 1 {
+				return ""
+			}
+			return string(text[:width-1]) + "…"
+		},
+		"default": func(fallback string, s any) string {
+			if text := fmt.Sprint(s); s != nil && text != "" && text != "0" {
+				return text
+			}
+			return fallback
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"join":  func(sep string, list []string) string { return strings.Join(list, sep) },
+	}
+}
+
+// formatAgo renders how long ago something happened, e.g. "5m ago".
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// parseTemplate compiles a template given on the command line. A value
+// without "{{" names a template from the config file.
+func parseTemplate(text string, cfg todo.Config, now time.Time) (*template.Template, error) {
+	name := "template"
+	if !strings.Contains(text, "{{") {
+		named, ok := cfg.Templates[text]
+		if !ok {
+			return nil, fmt.Errorf("no template named %q in the config file", text)
+		}
+		name, text = text, named
+	}
+	return template.New(name).Funcs(templateFuncs(now)).Parse(text)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"R2-D2/todo"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start [task ID]",
+	Short: "Start tracking time on a task",
+	Long: `Start tracking time on a task. Only one timer runs at a time: starting
+a new one stops the current timer. Timers are saved with the tasks, so they
+keep running between invocations.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error converting task ID to int:", err)
+			return
+		}
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		before := todo.CloneTasks(tasks)
+		stopped, err := todo.StartTimer(tasks, id, time.Now())
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if err := saveTasks("start", before, tasks); err != nil {
+			fmt.Println("Error saving tasks:", err)
+			return
+		}
+		if stopped != 0 {
+			fmt.Printf("Timer stopped for task %d\n", stopped)
+		}
+		fmt.Printf("Timer started for task %d\n", id)
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running timer",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := todo.LoadTasks("tasks.csv")
+		if err != nil {
+			fmt.Println("Error loading tasks:", err)
+			return
+		}
+		before := todo.CloneTasks(tasks)
+		id, elapsed, ok := todo.StopTimer(tasks, time.Now())
+		if !ok {
+			fmt.Println("No timer is running")
+			return
+		}
+		if err := saveTasks("stop", before, tasks); err != nil {
+			fmt.Println("Error saving tasks:", err)
+			return
+		}
+		fmt.Printf("Timer stopped for task %d after %s\n", id, formatDuration(elapsed))
+	},
+}
+
+// formatDuration renders a duration to the minute, e.g. "45m" or "2h30m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd, stopCmd)
+}
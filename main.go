@@ -2,8 +2,8 @@ package main
 
 import (
 	"R2-D2/cmd"
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -22,14 +22,21 @@ func main() {
 	fmt.Println("Welcome to R2-D2 Todo REPL")
 	fmt.Println("Type 'help' to see available commands, or 'exit' to quit")
 
-	scanner := bufio.NewScanner(os.Stdin)
+	// Read through cmd.Stdin, which confirmation prompts also read from,
+	// so a prompt gets the line after its command and not whatever a
+	// second buffer left over
+	var readErr error
 	for {
 		fmt.Print("> ")
-		if !scanner.Scan() {
+		line, err := cmd.Stdin.ReadString('\n')
+		if err != nil && line == "" {
+			if err != io.EOF {
+				readErr = err
+			}
 			break
 		}
 
-		input := scanner.Text()
+		input := strings.TrimRight(line, "\r\n")
 		if input == "exit" || input == "quit" {
 			break
 		}
@@ -53,7 +60,7 @@ func main() {
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error reading input:", err)
+	if readErr != nil {
+		fmt.Fprintln(os.Stderr, "Error reading input:", readErr)
 	}
 }
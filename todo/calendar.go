@@ -0,0 +1,113 @@
+package todo
+
+import (
+	"sort"
+	"time"
+)
+
+// CalendarDay is one day of a month view with the tasks that fall on it.
+// Days padding out the first and last week have a zero Date.
+type CalendarDay struct {
+	Date      time.Time // midnight, in the calendar's location
+	Due       []Task    // open tasks due that day
+	Completed []Task    // tasks completed that day
+}
+
+// Month lays out a month in weeks starting on first, placing each task on
+// the day it is due or was completed in loc.
+func Month(tasks []Task, year int, month time.Month, loc *time.Location, first time.Weekday) [][]CalendarDay {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	days := map[string]*CalendarDay{}
+	weeks := [][]CalendarDay{}
+	week := make([]CalendarDay, (int(start.Weekday())-int(first)+7)%7)
+	for day := start; day.Month() == month; day = day.AddDate(0, 0, 1) {
+		week = append(week, CalendarDay{Date: day})
+		if len(week) == 7 {
+			weeks = append(weeks, week)
+			week = []CalendarDay{}
+		}
+	}
+	if len(week) > 0 {
+		weeks = append(weeks, append(week, make([]CalendarDay, 7-len(week))...))
+	}
+	for w := range weeks {
+		for d := range weeks[w] {
+			if !weeks[w][d].Date.IsZero() {
+				days[weeks[w][d].Date.Format("2006-01-02")] = &weeks[w][d]
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		if task.Completed {
+			if day, ok := days[task.CompletedAt.In(loc).Format("2006-01-02")]; ok {
+				day.Completed = append(day.Completed, task)
+			}
+			continue
+		}
+		if task.Due.IsZero() {
+			continue
+		}
+		if day, ok := days[task.Due.In(loc).Format("2006-01-02")]; ok {
+			day.Due = append(day.Due, task)
+		}
+	}
+	return weeks
+}
+
+// AgendaDay lists what is due or scheduled on one day.
+type AgendaDay struct {
+	Date  time.Time
+	Items []AgendaItem
+}
+
+// AgendaItem is a task on an agenda day, there either because it is due
+// or because it is scheduled to start.
+type AgendaItem struct {
+	Task      Task
+	At        time.Time
+	Scheduled bool
+}
+
+// Agenda lists the open tasks due or scheduled on each of the given number
+// of days from from, a midnight, plus those already overdue at now, oldest
+// due date first.
+func Agenda(tasks []Task, from time.Time, days int, now time.Time) (overdue []Task, agenda []AgendaDay) {
+	index := map[string]int{}
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i)
+		index[day.Format("2006-01-02")] = i
+		agenda = append(agenda, AgendaDay{Date: day})
+	}
+	add := func(task Task, at time.Time, scheduled bool) {
+		if i, ok := index[at.In(from.Location()).Format("2006-01-02")]; ok {
+			agenda[i].Items = append(agenda[i].Items, AgendaItem{Task: task, At: at, Scheduled: scheduled})
+		}
+	}
+
+	for _, task := range tasks {
+		if task.Completed {
+			continue
+		}
+		if task.Overdue(now) {
+			overdue = append(overdue, task)
+		} else if !task.Due.IsZero() {
+			add(task, task.Due, false)
+		}
+		if !task.Scheduled.IsZero() && !task.Scheduled.Before(from) {
+			add(task, task.Scheduled, true)
+		}
+	}
+
+	sort.SliceStable(overdue, func(i, j int) bool { return overdue[i].Due.Before(overdue[j].Due) })
+	for _, day := range agenda {
+		sort.SliceStable(day.Items, func(i, j int) bool {
+			a, b := day.Items[i], day.Items[j]
+			if !a.At.Equal(b.At) {
+				return a.At.Before(b.At)
+			}
+			return priorityRank(a.Task.Priority) < priorityRank(b.Task.Priority)
+		})
+	}
+	return overdue, agenda
+}
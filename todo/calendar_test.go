@@ -0,0 +1,110 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonth(t *testing.T) {
+	loc := fixedClock().Location()
+	at := func(day, hour int) time.Time { return time.Date(2025, time.April, day, hour, 0, 0, 0, loc) }
+	tasks := []Task{
+		{ID: 1, Due: EndOfDay(at(4, 0))},
+		{ID: 2, Due: at(30, 23), Completed: true, CompletedAt: at(9, 12)},
+		// Due at 01:00 UTC on May 1 is still April 30 in BRT
+		{ID: 3, Due: time.Date(2025, time.May, 1, 1, 0, 0, 0, time.UTC)},
+		{ID: 4, Due: at(2, 9).AddDate(0, 1, 0)},
+	}
+
+	weeks := Month(tasks, 2025, time.April, loc, time.Sunday)
+	if len(weeks) != 5 {
+		t.Fatalf("got %d weeks, want 5", len(weeks))
+	}
+	// April 1, 2025 is a Tuesday: Sunday and Monday are padding
+	if !weeks[0][0].Date.IsZero() || !weeks[0][1].Date.IsZero() || weeks[0][2].Date.Day() != 1 {
+		t.Errorf("first week = %v", weeks[0])
+	}
+	if last := weeks[4]; last[3].Date.Day() != 30 || !last[4].Date.IsZero() {
+		t.Errorf("last week = %v", last)
+	}
+
+	find := func(day int) CalendarDay {
+		for _, week := range weeks {
+			for _, d := range week {
+				if !d.Date.IsZero() && d.Date.Day() == day {
+					return d
+				}
+			}
+		}
+		return CalendarDay{}
+	}
+	if d := find(4); len(d.Due) != 1 || d.Due[0].ID != 1 {
+		t.Errorf("April 4 = %+v, want task 1 due", d)
+	}
+	if d := find(9); len(d.Completed) != 1 || d.Completed[0].ID != 2 {
+		t.Errorf("April 9 = %+v, want task 2 completed", d)
+	}
+	if d := find(30); len(d.Due) != 1 || d.Due[0].ID != 3 {
+		t.Errorf("April 30 = %+v, want only task 3 due", d)
+	}
+
+	if weeks := Month(nil, 2025, time.April, loc, time.Monday); weeks[0][1].Date.Day() != 1 {
+		t.Errorf("with Monday first, April 1 is in column %v", weeks[0])
+	}
+}
+
+func TestAgenda(t *testing.T) {
+	now := fixedClock() // Wed Apr 2, 10:30
+	today := time.Date(2025, time.April, 2, 0, 0, 0, 0, now.Location())
+	tasks := []Task{
+		{ID: 1, Due: today.Add(9 * time.Hour)},                        // this morning, so overdue
+		{ID: 2, Due: EndOfDay(now.AddDate(0, 0, -3))},                 // overdue longer
+		{ID: 3, Due: EndOfDay(now)},                                   // today
+		{ID: 4, Due: today.Add(15 * time.Hour), Priority: "H"},        // today, earlier
+		{ID: 5, Scheduled: today.AddDate(0, 0, 1).Add(8 * time.Hour)}, // tomorrow
+		{ID: 6, Due: EndOfDay(now.AddDate(0, 0, 5))},                  // past the window
+		{ID: 7, Due: EndOfDay(now), Completed: true},
+	}
+	overdue, agenda := Agenda(tasks, today, 3, now)
+	if len(overdue) != 2 || overdue[0].ID != 2 || overdue[1].ID != 1 {
+		t.Errorf("overdue = %+v, want tasks 2 and 1", overdue)
+	}
+	if len(agenda) != 3 {
+		t.Fatalf("got %d days, want 3", len(agenda))
+	}
+	ids := func(day AgendaDay) []int {
+		list := []int{}
+		for _, item := range day.Items {
+			list = append(list, item.Task.ID)
+		}
+		return list
+	}
+	if got := ids(agenda[0]); len(got) != 2 || got[0] != 4 || got[1] != 3 {
+		t.Errorf("today = %v, want [4 3]", got)
+	}
+	if got := ids(agenda[1]); len(got) != 1 || got[0] != 5 || !agenda[1].Items[0].Scheduled {
+		t.Errorf("tomorrow = %+v, want task 5 scheduled", agenda[1].Items)
+	}
+	if got := ids(agenda[2]); len(got) != 0 {
+		t.Errorf("Friday = %v, want nothing", got)
+	}
+}
+
+func TestConfigWeekStartAndTimezone(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.FirstWeekday() != time.Monday || cfg.Location() != time.Local {
+		t.Errorf("defaults = %v, %v", cfg.FirstWeekday(), cfg.Location())
+	}
+	cfg.WeekStart = "Sunday"
+	if cfg.FirstWeekday() != time.Sunday {
+		t.Errorf("FirstWeekday = %v, want Sunday", cfg.FirstWeekday())
+	}
+	for _, bad := range []Config{{WeekStart: "someday"}, {Timezone: "Mars/Olympus"}} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", bad)
+		}
+	}
+	if got := StartOfWeekOn(fixedClock(), time.Sunday); got.Format("2006-01-02") != "2025-03-30" {
+		t.Errorf("StartOfWeekOn Sunday = %s, want 2025-03-30", got)
+	}
+}
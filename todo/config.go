@@ -0,0 +1,128 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Config holds user settings read from a JSON file next to the tasks.
+type Config struct {
+	Fields  []FieldDef     `json:"fields"`
+	Urgency UrgencyWeights `json:"urgency"`
+	Bulk    int            `json:"bulk"` // ask before changing more tasks than this; 0 never asks
+
+	// Templates are named output templates for list --template and
+	// status --template.
+	Templates map[string]string `json:"templates"`
+
+	// WeekStart names the first day of the week in calendar and agenda
+	// views, "monday" unless set. Timezone is the IANA zone they use,
+	// the system's own when empty.
+	WeekStart string `json:"week_start"`
+	Timezone  string `json:"timezone"`
+
+	location *time.Location
+}
+
+// DefaultConfig returns the settings used for anything the config file
+// leaves out.
+func DefaultConfig() Config {
+	return Config{Urgency: DefaultUrgency(), Bulk: 3}
+}
+
+// LoadConfig reads the config file on top of DefaultConfig. A missing file
+// is not an error and yields the defaults.
+func LoadConfig(filename string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, errors.New("failed to read config")
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config: %v", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config: %v", err)
+	}
+	if cfg.Timezone != "" {
+		cfg.location, _ = time.LoadLocation(cfg.Timezone)
+	}
+	return cfg, nil
+}
+
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// builtinFields cannot be redeclared as custom fields because filters and
+// sorts already give them a meaning.
+var builtinFields = map[string]bool{
+	"id": true, "description": true, "desc": true, "status": true, "created": true,
+	"completed": true, "due": true, "tag": true, "tags": true, "parent": true,
+	"depends": true, "recur": true, "estimate": true, "secret": true,
+	"priority": true, "urgency": true, "wait": true, "scheduled": true,
+}
+
+func (c Config) validate() error {
+	seen := map[string]bool{}
+	for _, f := range c.Fields {
+		if !fieldNamePattern.MatchString(f.Name) {
+			return fmt.Errorf("field name %q must be lower case letters, digits or _", f.Name)
+		}
+		if builtinFields[f.Name] {
+			return fmt.Errorf("field name %q is reserved", f.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("field %q is declared twice", f.Name)
+		}
+		seen[f.Name] = true
+		switch f.Type {
+		case "string", "number", "date":
+		case "enum":
+			if len(f.Values) == 0 {
+				return fmt.Errorf("enum field %q needs values", f.Name)
+			}
+		default:
+			return fmt.Errorf("field %q has unknown type %q", f.Name, f.Type)
+		}
+	}
+	if _, ok := weekdays[strings.ToLower(c.WeekStart)]; c.WeekStart != "" && !ok {
+		return fmt.Errorf("week_start %q is not a day of the week", c.WeekStart)
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", c.Timezone)
+	}
+	return nil
+}
+
+// FirstWeekday is the day weeks start on in calendar views.
+func (c Config) FirstWeekday() time.Weekday {
+	if wd, ok := weekdays[strings.ToLower(c.WeekStart)]; ok {
+		return wd
+	}
+	return time.Monday
+}
+
+// Location is the time zone calendar views use.
+func (c Config) Location() *time.Location {
+	if c.location != nil {
+		return c.location
+	}
+	return time.Local
+}
+
+// Field looks up a declared custom field by name.
+func (c Config) Field(name string) (FieldDef, bool) {
+	for _, f := range c.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FieldDef{}, false
+}
@@ -0,0 +1,223 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CycleError reports a dependency that would make a task wait on itself.
+type CycleError struct {
+	Path []int
+}
+
+func (e *CycleError) Error() string {
+	ids := []string{}
+	for _, id := range e.Path {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	return "dependency cycle: " + strings.Join(ids, " -> ")
+}
+
+// AddDependencies records that the task with the given ID cannot start
+// until each task in on is done. Edges that would close a cycle are
+// rejected and nothing is changed.
+func AddDependencies(tasks []Task, id int, on []int) error {
+	idx := indexOf(tasks, id)
+	if idx < 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+
+	deps := append([]int{}, tasks[idx].DependsOn...)
+	for _, dep := range on {
+		if dep == id {
+			return &CycleError{Path: []int{id, id}}
+		}
+		if indexOf(tasks, dep) < 0 {
+			return fmt.Errorf("task %d not found", dep)
+		}
+		if containsInt(deps, dep) {
+			continue
+		}
+		if path := dependencyPath(tasks, dep, id); path != nil {
+			return &CycleError{Path: append([]int{id}, path...)}
+		}
+		deps = append(deps, dep)
+	}
+	tasks[idx].DependsOn = deps
+	return nil
+}
+
+// RemoveDependencies drops the given edges from the task.
+func RemoveDependencies(tasks []Task, id int, on []int) error {
+	idx := indexOf(tasks, id)
+	if idx < 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+	kept := []int{}
+	for _, dep := range tasks[idx].DependsOn {
+		if !containsInt(on, dep) {
+			kept = append(kept, dep)
+		}
+	}
+	tasks[idx].DependsOn = kept
+	return nil
+}
+
+// dependencyPath returns the chain of IDs leading from one task to another
+// along DependsOn edges, or nil when there is none.
+func dependencyPath(tasks []Task, from, to int) []int {
+	deps := map[int][]int{}
+	for _, task := range tasks {
+		deps[task.ID] = task.DependsOn
+	}
+
+	seen := map[int]bool{}
+	var walk func(id int) []int
+	walk = func(id int) []int {
+		if id == to {
+			return []int{id}
+		}
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+		for _, dep := range deps[id] {
+			if path := walk(dep); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+		return nil
+	}
+	return walk(from)
+}
+
+// Blocked reports whether any task t depends on is still open. Dependencies
+// on tasks that no longer exist do not block.
+func Blocked(tasks []Task, t Task) bool {
+	for _, dep := range t.DependsOn {
+		if idx := indexOf(tasks, dep); idx >= 0 && !tasks[idx].Completed {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocking reports whether some open task is waiting on t.
+func Blocking(tasks []Task, t Task) bool {
+	if t.Completed {
+		return false
+	}
+	for _, task := range tasks {
+		if !task.Completed && containsInt(task.DependsOn, t.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ready reports whether t can be worked on now: it is open, not waiting or
+// scheduled for later, and nothing it depends on is still pending.
+func Ready(tasks []Task, t Task, now time.Time) bool {
+	if t.Completed || t.Waiting(now) || t.Scheduled.After(now) {
+		return false
+	}
+	return !Blocked(tasks, t)
+}
+
+// TopoSort orders tasks so that every task comes after the tasks it depends
+// on. Ties are broken by ID. Dependencies outside the given list are
+// ignored.
+func TopoSort(tasks []Task) ([]Task, error) {
+	byID := map[int]Task{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	waiting := map[int]int{}
+	dependents := map[int][]int{}
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if _, ok := byID[dep]; ok {
+				waiting[task.ID]++
+				dependents[dep] = append(dependents[dep], task.ID)
+			}
+		}
+	}
+
+	ready := []int{}
+	for _, task := range tasks {
+		if waiting[task.ID] == 0 {
+			ready = append(ready, task.ID)
+		}
+	}
+
+	ordered := []Task{}
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		id := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, byID[id])
+		for _, next := range dependents[id] {
+			waiting[next]--
+			if waiting[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	if len(ordered) != len(byID) {
+		return nil, fmt.Errorf("dependency graph contains a cycle")
+	}
+	return ordered, nil
+}
+
+// DependencyDOT renders the dependency graph in Graphviz DOT format, with an
+// edge from each task to the tasks waiting on it. label supplies the text
+// shown for each task.
+func DependencyDOT(tasks []Task, label func(Task) string) string {
+	present := map[int]bool{}
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, task := range tasks {
+		style := ""
+		if task.Completed {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  t%d [label=%s%s];\n", task.ID, strconv.Quote(fmt.Sprintf("%d: %s", task.ID, label(task))), style)
+	}
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if present[dep] {
+				fmt.Fprintf(&b, "  t%d -> t%d;\n", dep, task.ID)
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func indexOf(tasks []Task, id int) int {
+	for i, task := range tasks {
+		if task.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package todo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func depTasks() []Task {
+	return []Task{
+		{ID: 1, Description: "Deploy"},
+		{ID: 2, Description: "Build"},
+		{ID: 3, Description: "Test"},
+		{ID: 4, Description: "Write code"},
+	}
+}
+
+func TestAddDependencies(t *testing.T) {
+	tasks := depTasks()
+	if err := AddDependencies(tasks, 1, []int{2, 3}); err != nil {
+		t.Fatalf("AddDependencies() error = %v", err)
+	}
+	if err := AddDependencies(tasks, 3, []int{4}); err != nil {
+		t.Fatalf("AddDependencies() error = %v", err)
+	}
+	if err := AddDependencies(tasks, 1, []int{2}); err != nil {
+		t.Fatalf("re-adding an edge should be a no-op, got %v", err)
+	}
+	if !reflect.DeepEqual(tasks[0].DependsOn, []int{2, 3}) {
+		t.Errorf("task 1 depends on %v, want [2 3]", tasks[0].DependsOn)
+	}
+
+	err := AddDependencies(tasks, 4, []int{1})
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected a CycleError, got %v", err)
+	}
+	if !reflect.DeepEqual(cycle.Path, []int{4, 1, 3, 4}) {
+		t.Errorf("cycle path = %v, want [4 1 3 4]", cycle.Path)
+	}
+	if len(tasks[3].DependsOn) != 0 {
+		t.Errorf("rejected edge should not be stored, got %v", tasks[3].DependsOn)
+	}
+
+	if err := AddDependencies(tasks, 2, []int{2}); err == nil {
+		t.Errorf("self dependency should be rejected")
+	}
+	if err := AddDependencies(tasks, 2, []int{99}); err == nil {
+		t.Errorf("dependency on a missing task should be rejected")
+	}
+}
+
+func TestReadyAndBlocked(t *testing.T) {
+	tasks := depTasks()
+	AddDependencies(tasks, 1, []int{2})
+	now := fixedClock()
+	if Ready(tasks, tasks[0], now) || !Blocked(tasks, tasks[0]) {
+		t.Errorf("task 1 should be blocked while task 2 is open")
+	}
+	if !Blocking(tasks, tasks[1]) {
+		t.Errorf("task 2 should be blocking task 1")
+	}
+
+	tasks[1].Completed = true
+	if !Ready(tasks, tasks[0], now) {
+		t.Errorf("task 1 should be ready once task 2 is done")
+	}
+
+	tasks[0].Scheduled = now.Add(time.Hour)
+	if Ready(tasks, tasks[0], now) {
+		t.Errorf("task scheduled for later should not be ready")
+	}
+	tasks[0].Scheduled = time.Time{}
+	tasks[0].Wait = now.Add(time.Hour)
+	if Ready(tasks, tasks[0], now) {
+		t.Errorf("waiting task should not be ready")
+	}
+	if !Ready(tasks, tasks[0], now.Add(2*time.Hour)) {
+		t.Errorf("task should be ready once its wait date passes")
+	}
+	if Blocking(tasks, tasks[1]) {
+		t.Errorf("completed task should not be blocking")
+	}
+}
+
+func TestTopoSort(t *testing.T) {
+	tasks := depTasks()
+	AddDependencies(tasks, 1, []int{2, 3})
+	AddDependencies(tasks, 3, []int{4})
+	AddDependencies(tasks, 2, []int{4})
+
+	ordered, err := TopoSort(tasks)
+	if err != nil {
+		t.Fatalf("TopoSort() error = %v", err)
+	}
+	got := []int{}
+	for _, task := range ordered {
+		got = append(got, task.ID)
+	}
+	if !reflect.DeepEqual(got, []int{4, 2, 3, 1}) {
+		t.Errorf("TopoSort() = %v, want [4 2 3 1]", got)
+	}
+
+	tasks[3].DependsOn = []int{1} // corrupt the graph behind AddDependencies' back
+	if _, err := TopoSort(tasks); err == nil {
+		t.Errorf("TopoSort() should fail on a cycle")
+	}
+}
+
+func TestDependencyDOT(t *testing.T) {
+	tasks := depTasks()
+	AddDependencies(tasks, 1, []int{2})
+	dot := DependencyDOT(tasks, func(task Task) string { return task.Description })
+	for _, want := range []string{"digraph tasks {", `t1 [label="1: Deploy"];`, "t2 -> t1;"} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock reports the current time. Date parsing takes one so callers (and
+// tests) decide what "now" means.
+type Clock func() time.Time
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// ParseDate understands the date expressions accepted by --due:
+//
+//	today, tomorrow, yesterday, now
+//	mon..sun, next fri       the next such weekday after today
+//	in 3d, in 2w, 1m, in 4h  offsets in days, weeks, months, years, hours, minutes
+//	eow, eom, eoy            end of week (Sunday), month or year
+//	2025-04-10, 2025-04-10T15:04, RFC 3339
+//
+// Expressions that name a day resolve to the last second of that day so a
+// task due "today" is not overdue until the day is over.
+func ParseDate(input string, clock Clock) (time.Time, error) {
+	now := clock()
+	s := strings.ToLower(strings.Join(strings.Fields(input), " "))
+
+	switch s {
+	case "":
+		return time.Time{}, fmt.Errorf("empty date")
+	case "now":
+		return now, nil
+	case "today", "eod":
+		return EndOfDay(now), nil
+	case "tomorrow":
+		return EndOfDay(now.AddDate(0, 0, 1)), nil
+	case "yesterday":
+		return EndOfDay(now.AddDate(0, 0, -1)), nil
+	case "eow":
+		return EndOfDay(now.AddDate(0, 0, (7-int(now.Weekday()))%7)), nil
+	case "eom":
+		return EndOfDay(time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())), nil
+	case "eoy":
+		return EndOfDay(time.Date(now.Year(), time.December, 31, 0, 0, 0, 0, now.Location())), nil
+	}
+
+	if wd, ok := weekdays[strings.TrimPrefix(s, "next ")]; ok {
+		days := (int(wd) - int(now.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return EndOfDay(now.AddDate(0, 0, days)), nil
+	}
+
+	if t, ok := parseOffset(strings.TrimPrefix(s, "in "), now); ok {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return EndOfDay(t), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(s), now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", input)
+}
+
+// parseOffset handles "3d", "2 weeks", "1mo" and friends relative to now.
+func parseOffset(s string, now time.Time) (time.Time, bool) {
+	s = strings.ReplaceAll(s, " ", "")
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 || i == len(s) {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch s[i:] {
+	case "d", "day", "days":
+		return EndOfDay(now.AddDate(0, 0, n)), true
+	case "w", "wk", "week", "weeks":
+		return EndOfDay(now.AddDate(0, 0, 7*n)), true
+	case "m", "mo", "month", "months":
+		return EndOfDay(now.AddDate(0, n, 0)), true
+	case "y", "yr", "year", "years":
+		return EndOfDay(now.AddDate(n, 0, 0)), true
+	case "h", "hr", "hour", "hours":
+		return now.Add(time.Duration(n) * time.Hour), true
+	case "min", "mins", "minute", "minutes":
+		return now.Add(time.Duration(n) * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+// EndOfDay returns the last second of t's calendar day in t's location.
+func EndOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
+
+// Overdue reports whether the task is still open past its due date.
+func (t Task) Overdue(now time.Time) bool {
+	return !t.Completed && !t.Due.IsZero() && t.Due.Before(now)
+}
+
+// Waiting reports whether the task is still hidden behind its wait date.
+func (t Task) Waiting(now time.Time) bool {
+	return !t.Completed && !t.Wait.IsZero() && t.Wait.After(now)
+}
+
+// RelativeDue renders a due date relative to now in whole calendar days,
+// e.g. "today", "in 2 days" or "3 days overdue".
+func RelativeDue(due, now time.Time) string {
+	if due.IsZero() {
+		return ""
+	}
+	days := daysBetween(now, due)
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "1 day overdue"
+	case days > 1:
+		return fmt.Sprintf("in %d days", days)
+	default:
+		return fmt.Sprintf("%d days overdue", -days)
+	}
+}
+
+// daysBetween counts calendar days from a to b as seen in a's location.
+func daysBetween(a, b time.Time) int {
+	b = b.In(a.Location())
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
@@ -0,0 +1,122 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock pins "now" to Wednesday 2025-04-02 10:30 in UTC-3.
+func fixedClock() time.Time {
+	return time.Date(2025, time.April, 2, 10, 30, 0, 0, time.FixedZone("BRT", -3*60*60))
+}
+
+func TestParseDate(t *testing.T) {
+	loc := fixedClock().Location()
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 23, 59, 59, 0, loc)
+	}
+
+	testCases := []struct {
+		input string
+		want  time.Time
+	}{
+		{"today", day(2025, time.April, 2)},
+		{"tomorrow", day(2025, time.April, 3)},
+		{"yesterday", day(2025, time.April, 1)},
+		{"fri", day(2025, time.April, 4)},
+		{"next fri", day(2025, time.April, 4)},
+		{"Wednesday", day(2025, time.April, 9)},
+		{"in 3d", day(2025, time.April, 5)},
+		{"3 days", day(2025, time.April, 5)},
+		{"in 2w", day(2025, time.April, 16)},
+		{"in 1m", day(2025, time.May, 2)},
+		{"in 4h", time.Date(2025, time.April, 2, 14, 30, 0, 0, loc)},
+		{"eow", day(2025, time.April, 6)},
+		{"eom", day(2025, time.April, 30)},
+		{"eoy", day(2025, time.December, 31)},
+		{"2025-05-01", day(2025, time.May, 1)},
+		{"2025-05-01T09:15", time.Date(2025, time.May, 1, 9, 15, 0, 0, loc)},
+		{"2025-05-01T09:15:00Z", time.Date(2025, time.May, 1, 9, 15, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseDate(tc.input, fixedClock)
+			if err != nil {
+				t.Fatalf("ParseDate(%q) error = %v", tc.input, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseDate(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, input := range []string{"", "someday", "in d", "3 fortnights", "2025-13-01"} {
+		if _, err := ParseDate(input, fixedClock); err == nil {
+			t.Errorf("ParseDate(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestRelativeDue(t *testing.T) {
+	now := fixedClock()
+	testCases := []struct {
+		due  time.Time
+		want string
+	}{
+		{time.Time{}, ""},
+		{now.Add(2 * time.Hour), "today"},
+		{now.AddDate(0, 0, 1), "tomorrow"},
+		{now.AddDate(0, 0, 2), "in 2 days"},
+		{now.AddDate(0, 0, -1), "1 day overdue"},
+		{now.AddDate(0, 0, -3), "3 days overdue"},
+	}
+
+	for _, tc := range testCases {
+		if got := RelativeDue(tc.due, now); got != tc.want {
+			t.Errorf("RelativeDue(%v) = %q, want %q", tc.due, got, tc.want)
+		}
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	now := fixedClock()
+	testCases := []struct {
+		name string
+		task Task
+		want bool
+	}{
+		{"no due date", Task{}, false},
+		{"due later", Task{Due: now.Add(time.Hour)}, false},
+		{"past due", Task{Due: now.Add(-time.Hour)}, true},
+		{"past due but completed", Task{Due: now.Add(-time.Hour), Completed: true}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.task.Overdue(now); got != tc.want {
+			t.Errorf("%s: Overdue() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWaiting(t *testing.T) {
+	now := fixedClock()
+	testCases := []struct {
+		name string
+		task Task
+		want bool
+	}{
+		{"no wait date", Task{}, false},
+		{"wait in the future", Task{Wait: now.Add(time.Hour)}, true},
+		{"wait has passed", Task{Wait: now.Add(-time.Hour)}, false},
+		{"completed", Task{Wait: now.Add(time.Hour), Completed: true}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.task.Waiting(now); got != tc.want {
+			t.Errorf("%s: Waiting() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package todo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DumpVersion is the schema version written into JSON exports. Bump it
+// when a change would make older versions misread a dump.
+const DumpVersion = 1
+
+// Dump is everything in the store: tasks exactly as saved, secret ones
+// still encrypted, and the history undo works from.
+type Dump struct {
+	Version    int
+	ExportedAt time.Time
+	Tasks      []Task
+	History    []HistoryEntry
+}
+
+type dumpJSON struct {
+	Version    int         `json:"version"`
+	ExportedAt string      `json:"exported_at"`
+	Tasks      []taskJSON  `json:"tasks"`
+	History    []entryJSON `json:"history,omitempty"`
+}
+
+// dumpLine is one line of a JSON Lines export: a header, then one line
+// per task and history entry.
+type dumpLine struct {
+	Kind       string     `json:"kind"` // header, task or history
+	Version    int        `json:"version,omitempty"`
+	ExportedAt string     `json:"exported_at,omitempty"`
+	Task       *taskJSON  `json:"task,omitempty"`
+	Entry      *entryJSON `json:"entry,omitempty"`
+}
+
+type taskJSON struct {
+	ID          int               `json:"id"`
+	Description string            `json:"description"`
+	Encrypted   bool              `json:"encrypted,omitempty"`
+	Completed   bool              `json:"completed"`
+	CreatedAt   string            `json:"created"`
+	CompletedAt string            `json:"completed_at,omitempty"`
+	Priority    string            `json:"priority,omitempty"`
+	Due         string            `json:"due,omitempty"`
+	Wait        string            `json:"wait,omitempty"`
+	Scheduled   string            `json:"scheduled,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	ParentID    int               `json:"parent,omitempty"`
+	DependsOn   []int             `json:"depends,omitempty"`
+	Estimate    string            `json:"estimate,omitempty"`
+	Recur       string            `json:"recur,omitempty"`
+	RecurParent int               `json:"recur_parent,omitempty"`
+	RecurPaused bool              `json:"recur_paused,omitempty"`
+	RecurCount  int               `json:"recur_count,omitempty"`
+	Notes       []noteJSON        `json:"notes,omitempty"`
+	TimeLog     []intervalJSON    `json:"time_log,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+}
+
+type noteJSON struct {
+	At   string `json:"at"`
+	Text string `json:"text"`
+}
+
+type intervalJSON struct {
+	Start string `json:"start"`
+	End   string `json:"end,omitempty"`
+}
+
+type entryJSON struct {
+	Op      int       `json:"op"`
+	At      string    `json:"at"`
+	Command string    `json:"command"`
+	Undoes  int       `json:"undoes,omitempty"`
+	TaskID  int       `json:"task_id"`
+	Before  *taskJSON `json:"before,omitempty"`
+	After   *taskJSON `json:"after,omitempty"`
+}
+
+// WriteDump writes the dump as one indented JSON document, or as JSON
+// Lines when lines is set.
+func WriteDump(w io.Writer, d Dump, lines bool) error {
+	tasks := []taskJSON{}
+	for _, t := range d.Tasks {
+		tasks = append(tasks, newTaskJSON(t))
+	}
+	entries := []entryJSON{}
+	for _, e := range d.History {
+		entries = append(entries, newEntryJSON(e))
+	}
+
+	if !lines {
+		out := dumpJSON{Version: d.Version, ExportedAt: d.ExportedAt.Format(time.RFC3339), Tasks: tasks, History: entries}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(dumpLine{Kind: "header", Version: d.Version, ExportedAt: d.ExportedAt.Format(time.RFC3339)}); err != nil {
+		return err
+	}
+	for i := range tasks {
+		if err := encoder.Encode(dumpLine{Kind: "task", Task: &tasks[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range entries {
+		if err := encoder.Encode(dumpLine{Kind: "history", Entry: &entries[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadDump reads a dump written by WriteDump with the same lines setting.
+// Dumps from a newer schema version are refused rather than half read.
+func ReadDump(r io.Reader, lines bool) (Dump, error) {
+	var in dumpJSON
+	if !lines {
+		if err := json.NewDecoder(r).Decode(&in); err != nil {
+			return Dump{}, fmt.Errorf("not a JSON export: %v", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 16<<20)
+		n := 0
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			n++
+			var line dumpLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				return Dump{}, fmt.Errorf("line %d: %v", n, err)
+			}
+			switch {
+			case n == 1 && line.Kind != "header":
+				return Dump{}, errors.New("line 1: expected the export header")
+			case line.Kind == "header" && n == 1:
+				in.Version, in.ExportedAt = line.Version, line.ExportedAt
+			case line.Kind == "task" && line.Task != nil:
+				in.Tasks = append(in.Tasks, *line.Task)
+			case line.Kind == "history" && line.Entry != nil:
+				in.History = append(in.History, *line.Entry)
+			default:
+				return Dump{}, fmt.Errorf("line %d: unexpected %q line", n, line.Kind)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return Dump{}, err
+		}
+	}
+
+	switch {
+	case in.Version == 0:
+		return Dump{}, errors.New("not an r2d2 export: no schema version")
+	case in.Version > DumpVersion:
+		return Dump{}, fmt.Errorf("export has schema version %d but this r2d2 reads up to %d; upgrade it first", in.Version, DumpVersion)
+	}
+
+	d := Dump{Version: in.Version}
+	var err error
+	if in.ExportedAt != "" {
+		if d.ExportedAt, err = time.Parse(time.RFC3339, in.ExportedAt); err != nil {
+			return Dump{}, fmt.Errorf("exported_at: %v", err)
+		}
+	}
+	for i, tj := range in.Tasks {
+		t, err := tj.task()
+		if err != nil {
+			return Dump{}, fmt.Errorf("task %d (entry %d): %v", tj.ID, i+1, err)
+		}
+		d.Tasks = append(d.Tasks, t)
+	}
+	for i, ej := range in.History {
+		e, err := ej.entry()
+		if err != nil {
+			return Dump{}, fmt.Errorf("history entry %d: %v", i+1, err)
+		}
+		d.History = append(d.History, e)
+	}
+	return d, nil
+}
+
+func newTaskJSON(t Task) taskJSON {
+	out := taskJSON{
+		ID:          t.ID,
+		Description: t.Description,
+		Encrypted:   t.Encrypted,
+		Completed:   t.Completed,
+		CreatedAt:   formatOptionalTime(t.CreatedAt),
+		CompletedAt: formatOptionalTime(t.CompletedAt),
+		Priority:    t.Priority,
+		Due:         formatOptionalTime(t.Due),
+		Wait:        formatOptionalTime(t.Wait),
+		Scheduled:   formatOptionalTime(t.Scheduled),
+		Tags:        t.Tags,
+		ParentID:    t.ParentID,
+		DependsOn:   t.DependsOn,
+		Estimate:    formatOptionalDuration(t.Estimate),
+		Recur:       t.Recur,
+		RecurParent: t.RecurParent,
+		RecurPaused: t.RecurPaused,
+		RecurCount:  t.RecurCount,
+		Fields:      t.Fields,
+	}
+	for _, note := range t.Notes {
+		out.Notes = append(out.Notes, noteJSON{formatOptionalTime(note.At), note.Text})
+	}
+	for _, interval := range t.TimeLog {
+		out.TimeLog = append(out.TimeLog, intervalJSON{formatOptionalTime(interval.Start), formatOptionalTime(interval.End)})
+	}
+	return out
+}
+
+func (tj taskJSON) task() (Task, error) {
+	t := Task{
+		ID:          tj.ID,
+		Description: tj.Description,
+		Encrypted:   tj.Encrypted,
+		Completed:   tj.Completed,
+		Priority:    tj.Priority,
+		Tags:        tj.Tags,
+		ParentID:    tj.ParentID,
+		DependsOn:   tj.DependsOn,
+		Recur:       tj.Recur,
+		RecurParent: tj.RecurParent,
+		RecurPaused: tj.RecurPaused,
+		RecurCount:  tj.RecurCount,
+		Fields:      tj.Fields,
+	}
+	if t.ID <= 0 {
+		return Task{}, errors.New("missing or invalid id")
+	}
+	if priority, err := ParsePriority(t.Priority); err != nil || priority != t.Priority {
+		return Task{}, fmt.Errorf("invalid priority %q", t.Priority)
+	}
+	for _, tag := range t.Tags {
+		if normalized, ok := NormalizeTag(tag); !ok || normalized != tag {
+			return Task{}, fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+
+	var err error
+	times := []struct {
+		name  string
+		value string
+		into  *time.Time
+	}{
+		{"created", tj.CreatedAt, &t.CreatedAt},
+		{"completed_at", tj.CompletedAt, &t.CompletedAt},
+		{"due", tj.Due, &t.Due},
+		{"wait", tj.Wait, &t.Wait},
+		{"scheduled", tj.Scheduled, &t.Scheduled},
+	}
+	for _, field := range times {
+		if *field.into, err = parseOptionalTime(field.value); err != nil {
+			return Task{}, fmt.Errorf("%s: %v", field.name, err)
+		}
+	}
+	if t.Estimate, err = parseOptionalDuration(tj.Estimate); err != nil {
+		return Task{}, fmt.Errorf("estimate: %v", err)
+	}
+	for _, nj := range tj.Notes {
+		at, err := parseOptionalTime(nj.At)
+		if err != nil {
+			return Task{}, fmt.Errorf("note: %v", err)
+		}
+		t.Notes = append(t.Notes, Note{At: at, Text: nj.Text})
+	}
+	for _, ij := range tj.TimeLog {
+		start, err := parseOptionalTime(ij.Start)
+		if err != nil {
+			return Task{}, fmt.Errorf("time log: %v", err)
+		}
+		end, err := parseOptionalTime(ij.End)
+		if err != nil {
+			return Task{}, fmt.Errorf("time log: %v", err)
+		}
+		t.TimeLog = append(t.TimeLog, Interval{Start: start, End: end})
+	}
+	return t, nil
+}
+
+func newEntryJSON(e HistoryEntry) entryJSON {
+	out := entryJSON{Op: e.Op, At: e.At.Format(time.RFC3339), Command: e.Command, Undoes: e.Undoes, TaskID: e.TaskID}
+	if e.Before != nil {
+		before := newTaskJSON(*e.Before)
+		out.Before = &before
+	}
+	if e.After != nil {
+		after := newTaskJSON(*e.After)
+		out.After = &after
+	}
+	return out
+}
+
+func (ej entryJSON) entry() (HistoryEntry, error) {
+	e := HistoryEntry{Op: ej.Op, Command: ej.Command, Undoes: ej.Undoes, TaskID: ej.TaskID}
+	var err error
+	if e.At, err = time.Parse(time.RFC3339, ej.At); err != nil {
+		return e, fmt.Errorf("at: %v", err)
+	}
+	for _, side := range []struct {
+		from *taskJSON
+		into **Task
+	}{{ej.Before, &e.Before}, {ej.After, &e.After}} {
+		if side.from == nil {
+			continue
+		}
+		t, err := side.from.task()
+		if err != nil {
+			return e, err
+		}
+		*side.into = &t
+	}
+	return e, nil
+}
+
+// MergeResult describes how imported tasks were fitted into a store.
+type MergeResult struct {
+	Tasks    []Task      // the store with the imported tasks added, by ID
+	Added    []int       // IDs the imported tasks ended up with
+	Remapped map[int]int // imported ID -> new ID, for IDs already taken
+	Present  []int       // imported tasks identical to one already stored
+	Dropped  int         // parent, dependency and series references to tasks outside the import
+}
+
+// ConflictError lists imported IDs already used by different tasks.
+type ConflictError struct {
+	IDs []int
+}
+
+func (e *ConflictError) Error() string {
+	ids := []string{}
+	for _, id := range e.IDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	return "task IDs already in use by other tasks: " + strings.Join(ids, ", ")
+}
+
+// MergeTasks adds incoming tasks to existing ones. Tasks with ID 0 get
+// new IDs. A task identical to the stored one with its ID is already
+// there and skipped. Any other taken ID is a conflict: with remap the task
+// is given a new ID, and references to it from the other imported tasks
+// follow; without, a *ConflictError is returned. References to tasks that
+// are not part of the import are dropped, since on another machine the
+// same ID means another task.
+func MergeTasks(existing, incoming []Task, remap bool) (MergeResult, error) {
+	result := MergeResult{Remapped: map[int]int{}}
+	stored := map[int]Task{}
+	for _, t := range existing {
+		stored[t.ID] = t
+	}
+	next := max(NextID(existing), NextID(incoming))
+
+	ids := map[int]int{}
+	conflicts := []int{}
+	keep := make([]bool, len(incoming))
+	for i, t := range incoming {
+		if t.ID == 0 {
+			keep[i] = true
+			continue
+		}
+		if _, dup := ids[t.ID]; dup {
+			return MergeResult{}, fmt.Errorf("task %d appears twice in the import", t.ID)
+		}
+		old, taken := stored[t.ID]
+		switch {
+		case !taken:
+			ids[t.ID] = t.ID
+			keep[i] = true
+		case encodeRecord(taskRecord(old)) == encodeRecord(taskRecord(t)):
+			ids[t.ID] = t.ID
+			result.Present = append(result.Present, t.ID)
+		case remap:
+			ids[t.ID] = next
+			result.Remapped[t.ID] = next
+			next++
+			keep[i] = true
+		default:
+			conflicts = append(conflicts, t.ID)
+		}
+	}
+	if len(conflicts) > 0 {
+		return MergeResult{}, &ConflictError{IDs: conflicts}
+	}
+
+	follow := func(id int) int {
+		if id == 0 {
+			return 0
+		}
+		if mapped, ok := ids[id]; ok {
+			return mapped
+		}
+		result.Dropped++
+		return 0
+	}
+	result.Tasks = CloneTasks(existing)
+	for i, t := range CloneTasks(incoming) {
+		if !keep[i] {
+			continue
+		}
+		if t.ID == 0 {
+			t.ID = next
+			next++
+		} else {
+			t.ID = ids[t.ID]
+		}
+		t.ParentID = follow(t.ParentID)
+		t.RecurParent = follow(t.RecurParent)
+		depends := []int{}
+		for _, dep := range t.DependsOn {
+			if mapped := follow(dep); mapped != 0 {
+				depends = append(depends, mapped)
+			}
+		}
+		t.DependsOn = depends
+		result.Tasks = append(result.Tasks, t)
+		result.Added = append(result.Added, t.ID)
+	}
+	sort.SliceStable(result.Tasks, func(i, j int) bool { return result.Tasks[i].ID < result.Tasks[j].ID })
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package todo
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func dumpTestTasks(t *testing.T) []Task {
+	tasks := editTestTasks(t)
+	now := fixedClock()
+	tasks[0].CreatedAt = now.Add(-48 * time.Hour)
+	tasks[0].Notes = []Note{{At: now.Add(-time.Hour), Text: "ship it"}}
+	tasks[0].TimeLog = []Interval{{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}, {Start: now}}
+	tasks[1].Completed, tasks[1].CompletedAt = true, now
+	tasks[1].Wait, tasks[1].Scheduled = now.Add(time.Hour), now.Add(2*time.Hour)
+	tasks[2].Recur, tasks[2].RecurParent, tasks[2].RecurPaused = "FREQ=WEEKLY;BYDAY=MO", 1, true
+	return tasks
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	tasks := dumpTestTasks(t)
+	changed := CloneTasks(tasks)
+	changed[0].Priority = "H"
+	dump := Dump{
+		Version:    DumpVersion,
+		ExportedAt: fixedClock(),
+		Tasks:      tasks,
+		History: []HistoryEntry{
+			{Op: 1, At: fixedClock(), Command: "add", TaskID: 1, After: &tasks[0]},
+			{Op: 2, At: fixedClock(), Command: "modify", TaskID: 1, Before: &tasks[0], After: &changed[0]},
+		},
+	}
+
+	for _, lines := range []bool{false, true} {
+		var out bytes.Buffer
+		if err := WriteDump(&out, dump, lines); err != nil {
+			t.Fatalf("WriteDump: %v", err)
+		}
+		if !strings.Contains(out.String(), tasks[2].Description) {
+			t.Errorf("lines=%v: the secret task's ciphertext is missing", lines)
+		}
+		got, err := ReadDump(&out, lines)
+		if err != nil {
+			t.Fatalf("lines=%v: ReadDump: %v", lines, err)
+		}
+		if !got.ExportedAt.Equal(dump.ExportedAt) || !sameRecords(got.Tasks, tasks) {
+			t.Errorf("lines=%v: tasks changed:\n%+v\n%+v", lines, got.Tasks, tasks)
+		}
+		if len(got.History) != 2 || got.History[0].Before != nil || !sameRecords([]Task{*got.History[1].After}, changed[:1]) {
+			t.Errorf("lines=%v: history = %+v", lines, got.History)
+		}
+	}
+}
+
+func TestReadDumpVersion(t *testing.T) {
+	for input, want := range map[string]string{
+		`{"version": 2, "tasks": []}`:                     "schema version 2",
+		`{"tasks": []}`:                                   "no schema version",
+		`{"version": 1, "tasks": [{"description": "x"}]}`: "missing or invalid id",
+		`not json`: "not a JSON export",
+	} {
+		_, err := ReadDump(strings.NewReader(input), false)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %v, want %q", input, err, want)
+		}
+	}
+	_, err := ReadDump(strings.NewReader(`{"kind":"task","task":{"id":1}}`+"\n"), true)
+	if err == nil || !strings.Contains(err.Error(), "header") {
+		t.Errorf("JSON Lines without a header: error %v", err)
+	}
+}
+
+func TestMergeTasks(t *testing.T) {
+	existing := []Task{
+		{ID: 1, Description: "Release"},
+		{ID: 2, Description: "Write notes"},
+	}
+	incoming := []Task{
+		{ID: 1, Description: "Release"},
+		{ID: 2, Description: "Plan trip"},
+		{ID: 3, Description: "Book flights", ParentID: 2, DependsOn: []int{1, 2, 9}},
+		{Description: "From todo.txt"},
+	}
+
+	_, err := MergeTasks(existing, incoming, false)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !reflect.DeepEqual(conflict.IDs, []int{2}) {
+		t.Fatalf("without remap: error %v", err)
+	}
+
+	result, err := MergeTasks(existing, incoming, true)
+	if err != nil {
+		t.Fatalf("MergeTasks: %v", err)
+	}
+	if !reflect.DeepEqual(result.Added, []int{4, 3, 5}) || !reflect.DeepEqual(result.Remapped, map[int]int{2: 4}) ||
+		!reflect.DeepEqual(result.Present, []int{1}) || result.Dropped != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Tasks) != 5 {
+		t.Fatalf("merged %d tasks, want 5", len(result.Tasks))
+	}
+	flights := result.Tasks[2]
+	if flights.ID != 3 || flights.ParentID != 4 || !reflect.DeepEqual(flights.DependsOn, []int{1, 4}) {
+		t.Errorf("references not followed: %+v", flights)
+	}
+	if result.Tasks[1].Description != "Write notes" || result.Tasks[4].Description != "From todo.txt" {
+		t.Errorf("tasks = %+v", result.Tasks)
+	}
+
+	if _, err := MergeTasks(nil, []Task{{ID: 1}, {ID: 1}}, true); err == nil {
+		t.Error("duplicate IDs in the import: expected an error")
+	}
+}
@@ -0,0 +1,412 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// editableFields are the built-in fields edit and modify can change, in
+// the order edit shows them.
+var editableFields = []string{"description", "priority", "due", "wait", "scheduled", "estimate", "tags", "parent", "depends"}
+
+var fieldAliases = map[string]string{"desc": "description", "pri": "priority", "tag": "tags"}
+
+// EditableFields lists the built-in fields that can be changed on an
+// existing task followed by the custom fields from the config.
+func EditableFields(cfg Config) []string {
+	names := append([]string{}, editableFields...)
+	for _, def := range cfg.Fields {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// CanonicalField resolves a field name or alias such as "desc" to the name
+// EditableFields uses, reporting false for fields that cannot be changed.
+func CanonicalField(cfg Config, name string) (string, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := fieldAliases[name]; ok {
+		name = alias
+	}
+	if contains(editableFields, name) {
+		return name, true
+	}
+	if _, ok := cfg.Field(name); ok {
+		return name, true
+	}
+	return "", false
+}
+
+// FieldValue renders a field of the task the way SetTaskField reads it
+// back. Secret descriptions are decrypted.
+func FieldValue(t Task, name string) (string, error) {
+	switch name {
+	case "description":
+		if !t.Encrypted {
+			return t.Description, nil
+		}
+		return DecryptText(t.Description)
+	case "priority":
+		return t.Priority, nil
+	case "due":
+		return formatEditDate(t.Due), nil
+	case "wait":
+		return formatEditDate(t.Wait), nil
+	case "scheduled":
+		return formatEditDate(t.Scheduled), nil
+	case "estimate":
+		return formatEstimate(t.Estimate), nil
+	case "tags":
+		return strings.Join(t.Tags, ", "), nil
+	case "parent":
+		return formatOptionalInt(t.ParentID), nil
+	case "depends":
+		return strings.ReplaceAll(formatInts(t.DependsOn), " ", ", "), nil
+	default:
+		return t.Fields[name], nil
+	}
+}
+
+// SetTaskField parses value for the named field and stores it on the task
+// with the given ID; an empty value clears the field. Secret descriptions
+// are encrypted, and parents and dependencies are checked against the
+// other tasks.
+func SetTaskField(tasks []Task, id int, cfg Config, name, value string, clock Clock) error {
+	i := indexOf(tasks, id)
+	if i < 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+	field, ok := CanonicalField(cfg, name)
+	if !ok {
+		return fmt.Errorf("unknown field %q", name)
+	}
+	t := &tasks[i]
+	value = strings.TrimSpace(value)
+
+	var err error
+	switch field {
+	case "description":
+		if value == "" {
+			return errors.New("description cannot be empty")
+		}
+		if t.Encrypted {
+			value, err = EncryptText(value)
+			if err != nil {
+				return errors.New("failed to encrypt description")
+			}
+		}
+		t.Description = value
+	case "priority":
+		t.Priority, err = ParsePriority(value)
+	case "due":
+		t.Due, err = parseEditDate(field, value, clock)
+	case "wait":
+		t.Wait, err = parseEditDate(field, value, clock)
+	case "scheduled":
+		t.Scheduled, err = parseEditDate(field, value, clock)
+	case "estimate":
+		t.Estimate, err = parseEstimate(value)
+	case "tags":
+		t.Tags, err = parseTagList(value)
+	case "parent":
+		err = setParent(tasks, i, value)
+	case "depends":
+		err = setDependencies(tasks, i, value)
+	default:
+		if value == "" {
+			delete(t.Fields, field)
+			return nil
+		}
+		return SetField(t, cfg, field, value, clock)
+	}
+	return err
+}
+
+func formatEditDate(t time.Time) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case t.Equal(EndOfDay(t)):
+		return t.Format("2006-01-02")
+	default:
+		return t.Format("2006-01-02 15:04")
+	}
+}
+
+func parseEditDate(field, value string, clock Clock) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := ParseDate(value, clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %v", field, err)
+	}
+	return t, nil
+}
+
+// formatEstimate writes a duration without zero units, e.g. "2h" rather
+// than "2h0m0s".
+func formatEstimate(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func parseEstimate(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid estimate %q: use a duration like 45m or 2h30m", value)
+	}
+	return d, nil
+}
+
+// parseTagList reads tags separated by commas or spaces, with or without
+// a leading "+".
+func parseTagList(value string) ([]string, error) {
+	tags := []string{}
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		tag, ok := NormalizeTag(word)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q", word)
+		}
+		tags = AddTags(tags, tag)
+	}
+	return tags, nil
+}
+
+func parseIDList(field, value string) ([]int, error) {
+	ids := []int{}
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		id, err := strconv.Atoi(word)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("%s: invalid task ID %q", field, word)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// setParent moves a task under another one, refusing to make a task its
+// own ancestor.
+func setParent(tasks []Task, i int, value string) error {
+	ids, err := parseIDList("parent", value)
+	if err != nil {
+		return err
+	}
+	switch len(ids) {
+	case 0:
+		tasks[i].ParentID = 0
+		return nil
+	case 1:
+	default:
+		return errors.New("parent: a task has at most one parent")
+	}
+	parent := ids[0]
+	for id := parent; id != 0; {
+		if id == tasks[i].ID {
+			return fmt.Errorf("parent: task %d is a subtask of task %d", parent, tasks[i].ID)
+		}
+		j := indexOf(tasks, id)
+		if j < 0 {
+			return fmt.Errorf("parent: task %d not found", id)
+		}
+		id = tasks[j].ParentID
+	}
+	tasks[i].ParentID = parent
+	return nil
+}
+
+// setDependencies replaces what a task depends on, leaving it unchanged
+// if the new list is invalid or would close a cycle.
+func setDependencies(tasks []Task, i int, value string) error {
+	ids, err := parseIDList("depends", value)
+	if err != nil {
+		return err
+	}
+	old := tasks[i].DependsOn
+	tasks[i].DependsOn = nil
+	if err := AddDependencies(tasks, tasks[i].ID, ids); err != nil {
+		tasks[i].DependsOn = old
+		return fmt.Errorf("depends: %v", err)
+	}
+	return nil
+}
+
+// EditError is a problem in an edited task document, tied to its line.
+type EditError struct {
+	Line int
+	Msg  string
+}
+
+func (e *EditError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// FormatEditDoc renders tasks as "field: value" documents separated by
+// "---" lines, the text edit hands to $EDITOR. It looks like YAML but is
+// read line by line, with values taken as written. Secret descriptions and
+// notes appear decrypted.
+func FormatEditDoc(tasks []Task, cfg Config) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Change the values below and save to apply them. An empty value clears a\n")
+	b.WriteString("# field; lines starting with # are ignored. Dates read like --due\n")
+	b.WriteString("# (2025-04-10, 2025-04-10 15:04, tomorrow), estimates like 2h30m, priority\n")
+	b.WriteString("# is H, M or L, and tags, parent and depends take comma-separated lists.\n")
+	for i, t := range tasks {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		fmt.Fprintf(&b, "\nid: %d\n", t.ID)
+		for _, name := range EditableFields(cfg) {
+			value, err := FieldValue(t, name)
+			if err != nil {
+				return "", fmt.Errorf("task %d: failed to decrypt description", t.ID)
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+		if len(t.Notes) > 0 {
+			b.WriteString("# Notes (use annotate to add more):\n")
+		}
+		for _, note := range t.Notes {
+			text := note.Text
+			if t.Encrypted {
+				if decrypted, err := DecryptText(text); err == nil {
+					text = decrypted
+				}
+			}
+			fmt.Fprintf(&b, "#   %s  %s\n", note.At.Format("2006-01-02 15:04"), text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+type editedTask struct {
+	id     int
+	line   int
+	values map[string]string
+	lines  map[string]int
+}
+
+func parseEditDoc(text string, cfg Config) ([]editedTask, error) {
+	docs := []editedTask{}
+	errs := []error{}
+	var doc *editedTask
+	for n, line := range strings.Split(text, "\n") {
+		n++
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case trimmed == "---":
+			doc = nil
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !ok || key == "" {
+			errs = append(errs, &EditError{n, fmt.Sprintf("expected \"field: value\", got %q", trimmed)})
+			continue
+		}
+		if key == "id" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, &EditError{n, fmt.Sprintf("invalid task ID %q", value)})
+			}
+			docs = append(docs, editedTask{id: id, line: n, values: map[string]string{}, lines: map[string]int{}})
+			doc = &docs[len(docs)-1]
+			continue
+		}
+		if doc == nil {
+			errs = append(errs, &EditError{n, "each task must start with its id"})
+			continue
+		}
+		field, ok := CanonicalField(cfg, key)
+		if !ok {
+			errs = append(errs, &EditError{n, fmt.Sprintf("unknown field %q", key)})
+			continue
+		}
+		if _, seen := doc.values[field]; seen {
+			errs = append(errs, &EditError{n, fmt.Sprintf("%s is given twice", field)})
+			continue
+		}
+		doc.values[field] = value
+		doc.lines[field] = n
+	}
+	return docs, errors.Join(errs...)
+}
+
+// ApplyEdits reads back a document written by FormatEditDoc and applies
+// the values that changed. It returns the updated tasks and the IDs of
+// those that changed; on any error, with the lines at fault, tasks is left
+// as it was. Tasks missing from the document are left alone.
+func ApplyEdits(tasks []Task, text string, cfg Config, clock Clock) ([]Task, []int, error) {
+	docs, err := parseEditDoc(text, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	work := make([]Task, len(tasks))
+	copy(work, tasks)
+	changed := []int{}
+	errs := []error{}
+	seen := map[int]bool{}
+	for _, doc := range docs {
+		i := indexOf(work, doc.id)
+		switch {
+		case i < 0:
+			errs = append(errs, &EditError{doc.line, fmt.Sprintf("task %d not found", doc.id)})
+			continue
+		case seen[doc.id]:
+			errs = append(errs, &EditError{doc.line, fmt.Sprintf("task %d appears twice", doc.id)})
+			continue
+		}
+		seen[doc.id] = true
+		if work[i].Fields != nil {
+			fields := map[string]string{}
+			for name, value := range work[i].Fields {
+				fields[name] = value
+			}
+			work[i].Fields = fields
+		}
+
+		modified := false
+		for _, name := range EditableFields(cfg) {
+			value, ok := doc.values[name]
+			if !ok {
+				continue
+			}
+			old, err := FieldValue(work[i], name)
+			if err == nil && old == value {
+				continue
+			}
+			if err := SetTaskField(work, doc.id, cfg, name, value, clock); err != nil {
+				errs = append(errs, &EditError{doc.lines[name], err.Error()})
+				continue
+			}
+			modified = true
+		}
+		if modified {
+			changed = append(changed, doc.id)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return work, changed, nil
+}
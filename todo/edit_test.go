@@ -0,0 +1,109 @@
+package todo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func editTestTasks(t *testing.T) []Task {
+	secret, err := EncryptText("call the bank")
+	if err != nil {
+		t.Fatalf("EncryptText: %v", err)
+	}
+	due := EndOfDay(fixedClock())
+	return []Task{
+		{ID: 1, Description: "Release", Tags: []string{"work"}},
+		{ID: 2, Description: "Write notes", ParentID: 1, Due: due, Estimate: 90 * time.Minute, Priority: "M",
+			Fields: map[string]string{"points": "3"}},
+		{ID: 3, Description: secret, Encrypted: true, DependsOn: []int{2}},
+	}
+}
+
+func TestFormatEditDoc(t *testing.T) {
+	doc, err := FormatEditDoc(editTestTasks(t), testConfig())
+	if err != nil {
+		t.Fatalf("FormatEditDoc: %v", err)
+	}
+	for _, want := range []string{
+		"id: 2\ndescription: Write notes\npriority: M\ndue: 2025-04-02\nwait: \nscheduled: \nestimate: 1h30m\ntags: \nparent: 1\ndepends: \nticket: \npoints: 3\n",
+		"id: 3\ndescription: call the bank\n",
+		"depends: 2\n",
+		"---\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document lacks %q:\n%s", want, doc)
+		}
+	}
+}
+
+func TestApplyEdits(t *testing.T) {
+	cfg := testConfig()
+	tasks := editTestTasks(t)
+	doc, _ := FormatEditDoc(tasks, cfg)
+
+	if _, changed, err := ApplyEdits(tasks, doc, cfg, fixedClock); err != nil || len(changed) != 0 {
+		t.Fatalf("unchanged document: changed %v, err %v", changed, err)
+	}
+
+	doc = strings.Replace(doc, "description: Write notes", "description: Write release notes", 1)
+	doc = strings.Replace(doc, "priority: M", "priority: high", 1)
+	doc = strings.Replace(doc, "due: 2025-04-02", "due: tomorrow", 1)
+	doc = strings.Replace(doc, "points: 3", "points:", 1)
+	doc = strings.Replace(doc, "description: call the bank", "description: call the bank at 9", 1)
+	doc = strings.Replace(doc, "tags: work", "tags: +Work, home", 1)
+
+	updated, changed, err := ApplyEdits(tasks, doc, cfg, fixedClock)
+	if err != nil {
+		t.Fatalf("ApplyEdits: %v", err)
+	}
+	if !reflect.DeepEqual(changed, []int{1, 2, 3}) {
+		t.Errorf("changed = %v, want [1 2 3]", changed)
+	}
+	if got := updated[0].Tags; !reflect.DeepEqual(got, []string{"work", "home"}) {
+		t.Errorf("tags = %v", got)
+	}
+	second := updated[1]
+	if second.Description != "Write release notes" || second.Priority != "H" ||
+		!second.Due.Equal(EndOfDay(fixedClock().AddDate(0, 0, 1))) || second.Fields["points"] != "" {
+		t.Errorf("task 2 = %+v", second)
+	}
+	if tasks[1].Fields["points"] != "3" || tasks[1].Description != "Write notes" {
+		t.Errorf("the original tasks changed: %+v", tasks[1])
+	}
+	if text, err := DecryptText(updated[2].Description); err != nil || text != "call the bank at 9" {
+		t.Errorf("secret description = %q (%v), want it encrypted", updated[2].Description, err)
+	}
+}
+
+func TestApplyEditsErrors(t *testing.T) {
+	cfg := testConfig()
+	tasks := editTestTasks(t)
+
+	testCases := []struct {
+		doc  string
+		want []string
+	}{
+		{"id: 2\ncolour: red\n", []string{"line 2: unknown field \"colour\""}},
+		{"id: 2\ndue: someday\nsize: XL\n", []string{"line 2: due: unrecognized date", "line 3: size must be one of"}},
+		{"id: 1\nparent: 2\n", []string{"line 2: parent: task 2 is a subtask of task 1"}},
+		{"id: 2\ndepends: 3\n", []string{"line 2: depends: dependency cycle"}},
+		{"id: 9\ndescription: x\n", []string{"line 1: task 9 not found"}},
+		{"description: x\n", []string{"line 1: each task must start with its id"}},
+		{"id: 1\ndescription:\n", []string{"line 2: description cannot be empty"}},
+		{"id: 1\ntags: a\ntag: b\n", []string{"line 3: tags is given twice"}},
+	}
+	for _, tc := range testCases {
+		_, _, err := ApplyEdits(tasks, tc.doc, cfg, fixedClock)
+		if err == nil {
+			t.Errorf("ApplyEdits(%q) succeeded", tc.doc)
+			continue
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("ApplyEdits(%q) error = %q, want it to mention %q", tc.doc, err, want)
+			}
+		}
+	}
+}
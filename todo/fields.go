@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FieldDef declares a user-defined task field.
+type FieldDef struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"` // string, number, date or enum
+	Values []string `json:"values,omitempty"`
+}
+
+// Parse validates a value for the field and returns it in canonical form:
+// numbers without trailing zeros, dates as YYYY-MM-DD and enum values
+// spelled as declared.
+func (f FieldDef) Parse(value string, clock Clock) (string, error) {
+	switch f.Type {
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a number, got %q", f.Name, value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case "date":
+		t, err := ParseDate(value, clock)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a date: %v", f.Name, err)
+		}
+		return t.Format("2006-01-02"), nil
+	case "enum":
+		for _, allowed := range f.Values {
+			if strings.EqualFold(allowed, value) {
+				return allowed, nil
+			}
+		}
+		return "", fmt.Errorf("%s must be one of %s, got %q", f.Name, strings.Join(f.Values, ", "), value)
+	default:
+		return value, nil
+	}
+}
+
+// Compare orders two canonical values of the field: numerically, by date,
+// by declaration order for enums and alphabetically otherwise. Empty values
+// sort last.
+func (f FieldDef) Compare(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	switch f.Type {
+	case "number":
+		x, _ := strconv.ParseFloat(a, 64)
+		y, _ := strconv.ParseFloat(b, 64)
+		return compareFloats(x, y)
+	case "enum":
+		return indexOfString(f.Values, a) - indexOfString(f.Values, b)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// SetField validates and stores a custom field value on the task.
+func SetField(t *Task, cfg Config, name, value string, clock Clock) error {
+	def, ok := cfg.Field(name)
+	if !ok {
+		return fmt.Errorf("unknown field %q (declare it in the config file)", name)
+	}
+	canonical, err := def.Parse(value, clock)
+	if err != nil {
+		return err
+	}
+	if t.Fields == nil {
+		t.Fields = map[string]string{}
+	}
+	t.Fields[name] = canonical
+	return nil
+}
+
+// ParseAssignment splits a "key=value" argument.
+func ParseAssignment(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("expected key=value, got %q", s)
+	}
+	return strings.ToLower(key), strings.TrimSpace(value), nil
+}
+
+// formatFields stores custom fields URL-encoded, e.g. "customer=Acme&points=3".
+func formatFields(fields map[string]string) string {
+	values := url.Values{}
+	for key, value := range fields {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+func parseFields(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]string{}
+	for key := range values {
+		fields[key] = values.Get(key)
+	}
+	return fields, nil
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func indexOfString(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return len(list)
+}
@@ -0,0 +1,128 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testConfig() Config {
+	return Config{Fields: []FieldDef{
+		{Name: "ticket", Type: "string"},
+		{Name: "points", Type: "number"},
+		{Name: "review", Type: "date"},
+		{Name: "size", Type: "enum", Values: []string{"S", "M", "L"}},
+	}}
+}
+
+func TestFieldParse(t *testing.T) {
+	cfg := testConfig()
+	testCases := []struct {
+		field, input, want string
+		wantErr            bool
+	}{
+		{"ticket", "ABC-12", "ABC-12", false},
+		{"points", "3.50", "3.5", false},
+		{"points", "many", "", true},
+		{"review", "tomorrow", "2025-04-03", false},
+		{"review", "someday", "", true},
+		{"size", "m", "M", false},
+		{"size", "XL", "", true},
+	}
+
+	for _, tc := range testCases {
+		def, _ := cfg.Field(tc.field)
+		got, err := def.Parse(tc.input, fixedClock)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s.Parse(%q) error = %v, wantErr %v", tc.field, tc.input, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s.Parse(%q) = %q, want %q", tc.field, tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestSetField(t *testing.T) {
+	task := Task{ID: 1}
+	if err := SetField(&task, testConfig(), "points", "8", fixedClock); err != nil {
+		t.Fatalf("SetField() error = %v", err)
+	}
+	if task.Fields["points"] != "8" {
+		t.Errorf("points = %q, want 8", task.Fields["points"])
+	}
+	if err := SetField(&task, testConfig(), "customer", "Acme", fixedClock); err == nil {
+		t.Errorf("SetField() on an undeclared field should fail")
+	}
+}
+
+func TestSortTasksByField(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Fields: map[string]string{"points": "10", "size": "M"}},
+		{ID: 2, Fields: map[string]string{"points": "9", "size": "L"}},
+		{ID: 3},
+		{ID: 4, Fields: map[string]string{"points": "2.5", "size": "S"}},
+	}
+
+	ids := func() []int {
+		out := []int{}
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+
+	if err := SortTasks(tasks, "points", Context{Config: testConfig()}); err != nil {
+		t.Fatalf("SortTasks(points) error = %v", err)
+	}
+	if got := ids(); got[0] != 4 || got[1] != 2 || got[2] != 1 || got[3] != 3 {
+		t.Errorf("sorted by points = %v, want [4 2 1 3]", got)
+	}
+
+	if err := SortTasks(tasks, "size", Context{Config: testConfig()}); err != nil {
+		t.Fatalf("SortTasks(size) error = %v", err)
+	}
+	if got := ids(); got[0] != 4 || got[1] != 1 || got[2] != 2 || got[3] != 3 {
+		t.Errorf("sorted by size = %v, want [4 1 2 3]", got)
+	}
+
+	if err := SortTasks(tasks, "id", Context{Config: testConfig()}); err != nil || ids()[0] != 1 {
+		t.Errorf("SortTasks(id) = %v, %v", ids(), err)
+	}
+	if err := SortTasks(tasks, "colour", Context{Config: testConfig()}); err == nil {
+		t.Errorf("SortTasks() with an unknown key should fail")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := LoadConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || len(cfg.Fields) != 0 {
+		t.Errorf("missing config should give defaults, got %+v, %v", cfg, err)
+	}
+
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(`{"fields": [{"name": "points", "type": "number"}]}`), 0644)
+	cfg, err = LoadConfig(valid)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if def, ok := cfg.Field("points"); !ok || def.Type != "number" {
+		t.Errorf("points field not loaded: %+v", cfg)
+	}
+
+	for name, content := range map[string]string{
+		"syntax":   `{"fields": [`,
+		"type":     `{"fields": [{"name": "points", "type": "integer"}]}`,
+		"reserved": `{"fields": [{"name": "due", "type": "date"}]}`,
+		"enum":     `{"fields": [{"name": "size", "type": "enum"}]}`,
+		"name":     `{"fields": [{"name": "Story Points", "type": "number"}]}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: expected an invalid config error", name)
+		}
+	}
+}
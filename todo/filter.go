@@ -0,0 +1,753 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A Filter selects tasks with a small query language:
+//
+//	status:pending and (tag:work or priority>=H) and created.after:2025-04-01
+//	+work ^home due.before:eow desc~"deploy"
+//	3 5 8-12
+//
+// Terms are joined with and, or and not (also &&, || and !) and grouped
+// with parentheses; adjacent terms without an operator are and'ed, except
+// that neighbouring IDs and ID ranges form a single "any of" set. A term is
+// one of:
+//
+//	field<op>value   op is one of : = != < <= > >= ~ !~
+//	field.mod:value  mod is before, after, is or not
+//	+tag, ^tag       has or lacks the tag; -tag also lacks it
+//	3, 8-12, 3,5     task IDs
+//	word, "words"    description or notes contain the text
+//
+// Fields are id, status, priority, tag, desc, created, completed, due, wait,
+// scheduled, urgency, parent, depends and any custom field from the config.
+// An empty value matches tasks where the field is not set.
+type Filter struct {
+	root   filterNode // nil matches every task
+	fields map[string]bool
+}
+
+// ParseError reports where a filter query stopped making sense.
+type ParseError struct {
+	Query  string
+	Column int // 1-based, counted in characters
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// Caret renders the query with a caret under the offending column.
+func (e *ParseError) Caret() string {
+	return e.Query + "\n" + strings.Repeat(" ", e.Column-1) + "^"
+}
+
+// ParseFilter compiles a query. Field values are checked up front, with
+// custom fields typed by cfg and relative dates resolved with clock.
+func ParseFilter(query string, cfg Config, clock Clock) (*Filter, error) {
+	tokens, err := lexFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{query: query, tokens: tokens, cfg: cfg, clock: clock, fields: map[string]bool{}}
+	f := &Filter{fields: p.fields}
+	if p.peek().kind == tokEOF {
+		return f, nil
+	}
+	f.root, err = p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok.pos, "unexpected %q", tok.text)
+	}
+	return f, nil
+}
+
+// Match reports whether the task satisfies the filter.
+func (f *Filter) Match(t Task, ctx Context) bool {
+	return f.root == nil || f.root.match(t, ctx)
+}
+
+// Apply returns the tasks that match, in their original order.
+func (f *Filter) Apply(tasks []Task, ctx Context) []Task {
+	matched := []Task{}
+	for _, task := range tasks {
+		if f.Match(task, ctx) {
+			matched = append(matched, task)
+		}
+	}
+	return matched
+}
+
+// Empty reports whether the filter has no terms and so matches everything.
+func (f *Filter) Empty() bool {
+	return f.root == nil
+}
+
+// Uses reports whether any term of the filter tests the given field.
+func (f *Filter) Uses(field string) bool {
+	return f.fields[field]
+}
+
+type filterNode interface {
+	match(t Task, ctx Context) bool
+}
+
+type andNode []filterNode
+
+func (n andNode) match(t Task, ctx Context) bool {
+	for _, child := range n {
+		if !child.match(t, ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []filterNode
+
+func (n orNode) match(t Task, ctx Context) bool {
+	for _, child := range n {
+		if child.match(t, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	node filterNode
+}
+
+func (n notNode) match(t Task, ctx Context) bool {
+	return !n.node.match(t, ctx)
+}
+
+// idNode matches tasks whose ID falls in any of its inclusive ranges.
+type idNode struct {
+	ranges [][2]int
+}
+
+func (n *idNode) match(t Task, ctx Context) bool {
+	for _, r := range n.ranges {
+		if t.ID >= r[0] && t.ID <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+type predicate func(t Task, ctx Context) bool
+
+func (p predicate) match(t Task, ctx Context) bool {
+	return p(t, ctx)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokTerm
+)
+
+type token struct {
+	kind tokenKind
+	text string // as written, for error messages
+	pos  int    // rune offset into the query
+
+	// Set on terms. field is empty for bare words, tags and IDs.
+	field    string
+	op       string
+	value    string
+	quoted   bool
+	valuePos int
+}
+
+var filterOps = []string{"!=", "!~", "<=", ">=", ":", "=", "<", ">", "~"}
+
+// lexFilter splits a query into tokens.
+func lexFilter(query string) ([]token, error) {
+	src := []rune(query)
+	tokens := []token{}
+	fail := func(pos int, format string, args ...any) error {
+		return &ParseError{Query: query, Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+	}
+	// quoted reads a "..." string starting at i and returns it unescaped
+	// along with the position after the closing quote.
+	quoted := func(i int) (string, int, error) {
+		var b strings.Builder
+		for j := i + 1; j < len(src); j++ {
+			switch src[j] {
+			case '\\':
+				if j+1 < len(src) {
+					j++
+					b.WriteRune(src[j])
+				}
+			case '"':
+				return b.String(), j + 1, nil
+			default:
+				b.WriteRune(src[j])
+			}
+		}
+		return "", 0, fail(i, "unterminated quote")
+	}
+	endOfWord := func(i int) int {
+		for i < len(src) && !unicode.IsSpace(src[i]) && src[i] != '(' && src[i] != ')' {
+			i++
+		}
+		return i
+	}
+
+	for i := 0; i < len(src); {
+		r := src[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+			continue
+		case r == '"':
+			text, next, err := quoted(i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokTerm, text: string(src[i:next]), pos: i, value: text, quoted: true, valuePos: i})
+			i = next
+			continue
+		case r == '!' && (i+1 == len(src) || (src[i+1] != '=' && src[i+1] != '~')):
+			tokens = append(tokens, token{kind: tokNot, text: "!", pos: i})
+			i++
+			continue
+		case strings.HasPrefix(string(src[i:]), "&&"):
+			tokens = append(tokens, token{kind: tokAnd, text: "&&", pos: i})
+			i += 2
+			continue
+		case strings.HasPrefix(string(src[i:]), "||"):
+			tokens = append(tokens, token{kind: tokOr, text: "||", pos: i})
+			i += 2
+			continue
+		}
+
+		if tok, next, ok, err := lexFieldTerm(src, i, quoted, endOfWord); err != nil {
+			return nil, err
+		} else if ok {
+			tokens = append(tokens, tok)
+			i = next
+			continue
+		}
+
+		end := endOfWord(i)
+		word := string(src[i:end])
+		tok := token{kind: tokTerm, text: word, pos: i, value: word, valuePos: i}
+		switch strings.ToLower(word) {
+		case "and":
+			tok.kind = tokAnd
+		case "or":
+			tok.kind = tokOr
+		case "not":
+			tok.kind = tokNot
+		}
+		tokens = append(tokens, tok)
+		i = end
+	}
+	return tokens, nil
+}
+
+// lexFieldTerm reads a field<op>value term starting at i, reporting false
+// when the text there is not one.
+func lexFieldTerm(src []rune, i int, quoted func(int) (string, int, error), endOfWord func(int) int) (token, int, bool, error) {
+	j := i
+	for j < len(src) && (unicode.IsLetter(src[j]) || unicode.IsDigit(src[j]) || src[j] == '_' || src[j] == '.') {
+		j++
+	}
+	if j == i || !unicode.IsLetter(src[i]) {
+		return token{}, 0, false, nil
+	}
+	for _, op := range filterOps {
+		if !strings.HasPrefix(string(src[j:]), op) {
+			continue
+		}
+		tok := token{kind: tokTerm, pos: i, field: strings.ToLower(string(src[i:j])), op: op}
+		k := j + len(op)
+		tok.valuePos = k
+		if k < len(src) && src[k] == '"' {
+			text, next, err := quoted(k)
+			if err != nil {
+				return token{}, 0, false, err
+			}
+			tok.value, tok.quoted, k = text, true, next
+		} else {
+			end := endOfWord(k)
+			tok.value, k = string(src[k:end]), end
+		}
+		tok.text = string(src[i:k])
+		return tok, k, true, nil
+	}
+	return token{}, 0, false, nil
+}
+
+type filterParser struct {
+	query  string
+	tokens []token
+	next   int
+	cfg    Config
+	clock  Clock
+	fields map[string]bool
+}
+
+func (p *filterParser) peek() token {
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next]
+	}
+	return token{kind: tokEOF, text: "end of filter", pos: len([]rune(p.query))}
+}
+
+func (p *filterParser) advance() token {
+	tok := p.peek()
+	p.next++
+	return tok
+}
+
+func (p *filterParser) errorAt(pos int, format string, args ...any) error {
+	return &ParseError{Query: p.query, Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := orNode{left}
+	for p.peek().kind == tokOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return nodes, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	first, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	nodes := andNode{first}
+	for {
+		explicit := false
+		switch p.peek().kind {
+		case tokAnd:
+			p.advance()
+			explicit = true
+		case tokTerm, tokLParen, tokNot:
+		default:
+			if len(nodes) == 1 {
+				return first, nil
+			}
+			return nodes, nil
+		}
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		// "3 5 8-12" means any of those tasks, not all of them at once
+		last, lastIsID := nodes[len(nodes)-1].(*idNode)
+		if ids, ok := node.(*idNode); ok && lastIsID && !explicit {
+			last.ranges = append(last.ranges, ids.ranges...)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+}
+
+func (p *filterParser) parseNot() (filterNode, error) {
+	if p.peek().kind == tokNot {
+		p.advance()
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != tokRParen {
+			return nil, p.errorAt(closing.pos, "expected \")\" to close the \"(\" at column %d", tok.pos+1)
+		}
+		p.advance()
+		return node, nil
+	case tokTerm:
+		return p.compileTerm(tok)
+	case tokEOF:
+		return nil, p.errorAt(tok.pos, "expected a filter term")
+	default:
+		return nil, p.errorAt(tok.pos, "unexpected %q", tok.text)
+	}
+}
+
+// compileTerm turns a single term into something that can be matched.
+func (p *filterParser) compileTerm(tok token) (filterNode, error) {
+	if tok.field == "" {
+		return p.compileWord(tok)
+	}
+
+	field, op := tok.field, tok.op
+	if base, mod, ok := strings.Cut(field, "."); ok {
+		if op != ":" && op != "=" {
+			return nil, p.errorAt(tok.valuePos-len(op), "use %s:value with a modifier", field)
+		}
+		switch mod {
+		case "before":
+			op = "<"
+		case "after":
+			op = ">"
+		case "is":
+			op = "="
+		case "not":
+			op = "!="
+		default:
+			return nil, p.errorAt(tok.pos+len([]rune(base))+1, "unknown modifier %q (use before, after, is or not)", mod)
+		}
+		field = base
+	}
+	if op == ":" {
+		op = "="
+	}
+	if field == "description" {
+		field = "desc"
+	}
+	if field == "tags" {
+		field = "tag"
+	}
+	if field == "pri" {
+		field = "priority"
+	}
+	p.fields[field] = true
+
+	value := tok.value
+	bad := func(format string, args ...any) error {
+		return p.errorAt(tok.valuePos, format, args...)
+	}
+	ordered := op != "=" && op != "!=" && op != "~" && op != "!~"
+	if op == "~" || op == "!~" {
+		if field != "desc" && field != "tag" {
+			if def, ok := p.cfg.Field(field); !ok || def.Type != "string" {
+				return nil, p.errorAt(tok.valuePos-len(op), "%s only works on text fields", op)
+			}
+		}
+	}
+	if ordered && value == "" {
+		return nil, bad("expected a value after %q", field+tok.op)
+	}
+
+	switch field {
+	case "id", "parent", "depends":
+		n, err := strconv.Atoi(value)
+		if value != "" && (err != nil || n < 1) {
+			return nil, bad("%s must be a task ID, got %q", field, value)
+		}
+		get := func(t Task) []int {
+			switch field {
+			case "id":
+				return []int{t.ID}
+			case "parent":
+				if t.ParentID == 0 {
+					return nil
+				}
+				return []int{t.ParentID}
+			}
+			return t.DependsOn
+		}
+		if field == "depends" && ordered {
+			return nil, bad("depends only supports : and !=")
+		}
+		return predicate(func(t Task, ctx Context) bool {
+			ids := get(t)
+			if value == "" {
+				return (len(ids) == 0) == (op == "=")
+			}
+			if op == "!=" {
+				return !containsInt(ids, n)
+			}
+			for _, id := range ids {
+				if compareOp(op, id-n) {
+					return true
+				}
+			}
+			return false
+		}), nil
+
+	case "status":
+		if ordered {
+			return nil, bad("status only supports : and !=")
+		}
+		check, ok := statusChecks[strings.ToLower(value)]
+		if !ok {
+			return nil, bad("unknown status %q (use pending, done, waiting, ready, blocked or overdue)", value)
+		}
+		return predicate(func(t Task, ctx Context) bool {
+			return check(t, ctx) == (op == "=")
+		}), nil
+
+	case "priority":
+		priority, err := ParsePriority(value)
+		if err != nil {
+			return nil, bad("%v", err)
+		}
+		// Higher priorities compare greater: H > M > L > none
+		want := 3 - priorityRank(priority)
+		return predicate(func(t Task, ctx Context) bool {
+			have := 3 - priorityRank(t.Priority)
+			if ordered && have == 0 {
+				return false
+			}
+			return compareOp(op, have-want)
+		}), nil
+
+	case "tag":
+		if ordered {
+			return nil, bad("tag only supports :, != and ~")
+		}
+		tag := strings.ToLower(strings.TrimPrefix(value, "+"))
+		if value != "" && op != "~" && op != "!~" {
+			normalized, ok := NormalizeTag(value)
+			if !ok {
+				return nil, bad("invalid tag %q", value)
+			}
+			tag = normalized
+		}
+		return predicate(func(t Task, ctx Context) bool {
+			var hit bool
+			switch {
+			case value == "":
+				hit = len(t.Tags) == 0
+			case op == "~" || op == "!~":
+				for _, have := range t.Tags {
+					if strings.Contains(have, tag) {
+						hit = true
+					}
+				}
+			default:
+				hit = t.HasTag(tag)
+			}
+			return hit == (op == "=" || op == "~")
+		}), nil
+
+	case "desc":
+		if ordered {
+			return nil, bad("desc only supports :, !=, ~ and !~")
+		}
+		return predicate(func(t Task, ctx Context) bool {
+			desc, ok := plainDescription(t, ctx.Reveal)
+			if !ok {
+				return false
+			}
+			var hit bool
+			if op == "~" || op == "!~" {
+				hit = strings.Contains(strings.ToLower(desc), strings.ToLower(value))
+			} else {
+				hit = strings.EqualFold(desc, value)
+			}
+			return hit == (op == "=" || op == "~")
+		}), nil
+
+	case "created", "completed", "due", "wait", "scheduled":
+		get := dateFields[field]
+		if value == "" {
+			return predicate(func(t Task, ctx Context) bool {
+				return get(t).IsZero() == (op == "=")
+			}), nil
+		}
+		when, err := ParseDate(value, p.clock)
+		if err != nil {
+			return nil, bad("%v", err)
+		}
+		start, end := when, when
+		if when.Equal(EndOfDay(when)) {
+			// A whole day: "before" it ends where the day starts
+			start = time.Date(when.Year(), when.Month(), when.Day(), 0, 0, 0, 0, when.Location())
+		}
+		return predicate(func(t Task, ctx Context) bool {
+			v := get(t)
+			if v.IsZero() {
+				return op == "!="
+			}
+			switch op {
+			case "=":
+				return !v.Before(start) && !v.After(end)
+			case "!=":
+				return v.Before(start) || v.After(end)
+			case "<":
+				return v.Before(start)
+			case "<=":
+				return !v.After(end)
+			case ">":
+				return v.After(end)
+			default:
+				return !v.Before(start)
+			}
+		}), nil
+
+	case "urgency":
+		want, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, bad("urgency must be a number, got %q", value)
+		}
+		return predicate(func(t Task, ctx Context) bool {
+			return compareOp(op, compareFloats(Urgency(t, ctx), want))
+		}), nil
+	}
+
+	def, ok := p.cfg.Field(field)
+	if !ok {
+		return nil, p.errorAt(tok.pos, "unknown field %q", field)
+	}
+	canonical := value
+	if value != "" && op != "~" && op != "!~" {
+		var err error
+		canonical, err = def.Parse(value, p.clock)
+		if err != nil {
+			return nil, bad("%v", err)
+		}
+	}
+	return predicate(func(t Task, ctx Context) bool {
+		have := t.Fields[def.Name]
+		switch op {
+		case "=":
+			return have == canonical
+		case "!=":
+			return have != canonical
+		case "~", "!~":
+			hit := strings.Contains(strings.ToLower(have), strings.ToLower(value))
+			return hit == (op == "~")
+		}
+		return have != "" && compareOp(op, def.Compare(have, canonical))
+	}), nil
+}
+
+// compileWord handles terms without a field: tags, IDs and free text.
+func (p *filterParser) compileWord(tok token) (filterNode, error) {
+	word := tok.value
+	if !tok.quoted && len(word) > 1 && strings.ContainsRune("+^-", rune(word[0])) {
+		tag, ok := NormalizeTag(word[1:])
+		if !ok {
+			return nil, p.errorAt(tok.pos+1, "invalid tag %q", word[1:])
+		}
+		p.fields["tag"] = true
+		want := word[0] == '+'
+		return predicate(func(t Task, ctx Context) bool {
+			return t.HasTag(tag) == want
+		}), nil
+	}
+
+	if !tok.quoted && word != "" && (word[0] >= '0' && word[0] <= '9') {
+		ids := &idNode{}
+		pos := tok.pos
+		for _, part := range strings.Split(word, ",") {
+			from, to, isRange := strings.Cut(part, "-")
+			lo, err1 := strconv.Atoi(from)
+			hi, err2 := lo, error(nil)
+			if isRange {
+				hi, err2 = strconv.Atoi(to)
+			}
+			if err1 != nil || err2 != nil || lo < 1 {
+				return nil, p.errorAt(pos, "invalid task ID or range %q", part)
+			}
+			if hi < lo {
+				return nil, p.errorAt(pos, "range %q runs backwards", part)
+			}
+			ids.ranges = append(ids.ranges, [2]int{lo, hi})
+			pos += len(part) + 1
+		}
+		p.fields["id"] = true
+		return ids, nil
+	}
+
+	return predicate(func(t Task, ctx Context) bool {
+		return MatchText(t, word, ctx.Reveal)
+	}), nil
+}
+
+// compareOp applies a comparison operator to the result of a three-way
+// comparison.
+func compareOp(op string, c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+var statusChecks = map[string]func(t Task, ctx Context) bool{
+	"pending":   func(t Task, ctx Context) bool { return !t.Completed },
+	"done":      func(t Task, ctx Context) bool { return t.Completed },
+	"completed": func(t Task, ctx Context) bool { return t.Completed },
+	"waiting":   func(t Task, ctx Context) bool { return t.Waiting(ctx.Now) },
+	"ready":     func(t Task, ctx Context) bool { return Ready(ctx.Tasks, t, ctx.Now) },
+	"blocked":   func(t Task, ctx Context) bool { return !t.Completed && Blocked(ctx.Tasks, t) },
+	"overdue":   func(t Task, ctx Context) bool { return t.Overdue(ctx.Now) },
+}
+
+var dateFields = map[string]func(t Task) time.Time{
+	"created":   func(t Task) time.Time { return t.CreatedAt },
+	"completed": func(t Task) time.Time { return t.CompletedAt },
+	"due":       func(t Task) time.Time { return t.Due },
+	"wait":      func(t Task) time.Time { return t.Wait },
+	"scheduled": func(t Task) time.Time { return t.Scheduled },
+}
+
+// plainDescription returns the task's description in clear text. It
+// reports false for a secret task when reveal is off or decryption fails.
+func plainDescription(t Task, reveal bool) (string, bool) {
+	if !t.Encrypted {
+		return t.Description, true
+	}
+	if !reveal {
+		return "", false
+	}
+	text, err := DecryptText(t.Description)
+	return text, err == nil
+}
@@ -0,0 +1,162 @@
+package todo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func filterTasks() []Task {
+	now := fixedClock()
+	return []Task{
+		{ID: 1, Description: "Deploy the API", CreatedAt: now.AddDate(0, 0, -10), Tags: []string{"work"}, Priority: "H"},
+		{ID: 2, Description: "Buy milk", CreatedAt: now.AddDate(0, 0, -1), Tags: []string{"home"}, Due: EndOfDay(now)},
+		{ID: 3, Description: "Write deploy notes", CreatedAt: now, Tags: []string{"work", "docs"}, Priority: "L", DependsOn: []int{1}},
+		{ID: 4, Description: "File taxes", CreatedAt: now.AddDate(0, -2, 0), Completed: true, CompletedAt: now.AddDate(0, 0, -3)},
+		{ID: 5, Description: "Renew passport", CreatedAt: now, Wait: now.AddDate(0, 0, 5), Priority: "M",
+			Fields: map[string]string{"points": "8", "size": "L"}},
+		{ID: 6, Description: "Fix login", CreatedAt: now, Fields: map[string]string{"points": "2", "size": "S"}, Priority: "M"},
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	tasks := filterTasks()
+	ctx := Context{Tasks: tasks, Config: testConfig(), Now: fixedClock()}
+
+	testCases := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3, 4, 5, 6}},
+		{"+work", []int{1, 3}},
+		{"+work -docs", []int{1}},
+		{"+work ^docs", []int{1}},
+		{"tag:home or tag:docs", []int{2, 3}},
+		{"status:pending and (tag:work or priority>=H)", []int{1, 3}},
+		{"priority>=M", []int{1, 5, 6}},
+		{"priority<M", []int{3}},
+		{"priority:", []int{2, 4}},
+		{"status:done", []int{4}},
+		{"status:completed", []int{4}},
+		{"status:waiting", []int{5}},
+		{"status:blocked", []int{3}},
+		{"status:ready", []int{1, 2, 6}},
+		{"not status:pending", []int{4}},
+		{"!+work", []int{2, 4, 5, 6}},
+		{`desc~"deploy"`, []int{1, 3}},
+		{"desc~DEPLOY and not id:1", []int{3}},
+		{`desc:"buy milk"`, []int{2}},
+		{"deploy", []int{1, 3}},
+		{"created.after:2025-04-01", []int{3, 5, 6}},
+		{"created.before:2025-04-01", []int{1, 4}},
+		{"created:yesterday", []int{2}},
+		{"due:today", []int{2}},
+		{"due:", []int{1, 3, 4, 5, 6}},
+		{"due!=", []int{2}},
+		{"completed.after:2025-03-01", []int{4}},
+		{"3 5 1", []int{1, 3, 5}},
+		{"2-4", []int{2, 3, 4}},
+		{"1,5-6", []int{1, 5, 6}},
+		{"1-3 +work", []int{1, 3}},
+		{"1-3 and 3-6", []int{3}},
+		{"id>4", []int{5, 6}},
+		{"depends:1", []int{3}},
+		{"points>=3", []int{5}},
+		{"size:s", []int{6}},
+		{"size:", []int{1, 2, 3, 4}},
+		{"tag~wor", []int{1, 3}},
+		{"+work && priority:h || id:2", []int{1, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			f, err := ParseFilter(tc.query, ctx.Config, fixedClock)
+			if err != nil {
+				t.Fatalf("ParseFilter: %v", err)
+			}
+			got := []int{}
+			for _, task := range f.Apply(tasks, ctx) {
+				got = append(got, task.ID)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilterSecretText(t *testing.T) {
+	encrypted, err := EncryptText("launch codes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := []Task{{ID: 1, Description: encrypted, Encrypted: true, CreatedAt: time.Now()}}
+	f, err := ParseFilter("desc~launch", Config{}, fixedClock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Match(tasks[0], Context{Tasks: tasks}) {
+		t.Error("secret task matched without reveal")
+	}
+	if !f.Match(tasks[0], Context{Tasks: tasks, Reveal: true}) {
+		t.Error("secret task did not match with reveal")
+	}
+}
+
+func TestFilterParseErrors(t *testing.T) {
+	testCases := []struct {
+		query  string
+		column int
+	}{
+		{"status:pending and (tag:work or priority>=H", 44},
+		{"status:pending and", 19},
+		{"priority>=", 11},
+		{"priority:urgent", 10},
+		{"colour:red", 1},
+		{"status:sleeping", 8},
+		{"due.before:someday", 12},
+		{"due.around:today", 5},
+		{`desc~"deploy`, 6},
+		{"+work )", 7},
+		{"or +work", 1},
+		{"5-3", 1},
+		{"1,x", 3},
+		{"+bad!tag", 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseFilter(tc.query, testConfig(), fixedClock)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if perr.Column != tc.column {
+				t.Errorf("column = %d, want %d (%v)", perr.Column, tc.column, err)
+			}
+		})
+	}
+}
+
+func TestParseErrorCaret(t *testing.T) {
+	_, err := ParseFilter("+work and priority>=", Config{}, fixedClock)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	want := "+work and priority>=\n                    ^"
+	if perr.Caret() != want {
+		t.Errorf("Caret() =\n%s\nwant\n%s", perr.Caret(), want)
+	}
+}
+
+func TestFilterUses(t *testing.T) {
+	f, err := ParseFilter("+work or status:waiting", Config{}, fixedClock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Uses("status") || !f.Uses("tag") || f.Uses("due") {
+		t.Errorf("Uses reported the wrong fields: %v", f.fields)
+	}
+}
@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// FlowPoint counts, at the end of Day, the tasks created and completed so
+// far and those still open.
+type FlowPoint struct {
+	Day       time.Time
+	Created   int
+	Completed int
+	Open      int
+}
+
+// Flow returns one FlowPoint per calendar day from from to to, both
+// included, for burndown and cumulative-flow charts.
+func Flow(tasks []Task, from, to time.Time) []FlowPoint {
+	points := []FlowPoint{}
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for !day.After(to) {
+		end := EndOfDay(day)
+		point := FlowPoint{Day: day}
+		for _, task := range tasks {
+			if task.CreatedAt.After(end) {
+				continue
+			}
+			point.Created++
+			if task.Completed && !task.CompletedAt.After(end) {
+				point.Completed++
+			}
+		}
+		point.Open = point.Created - point.Completed
+		points = append(points, point)
+		day = day.AddDate(0, 0, 1)
+	}
+	return points
+}
+
+var sincePattern = regexp.MustCompile(`^(\d+)\s*(d|w|m|y)$`)
+
+// ParseSince reads how far back a report starts: a span such as "30d",
+// "6w", "3m" or "1y" back from today, or a date in any form ParseDate
+// takes. The result is the start of that day.
+func ParseSince(input string, clock Clock) (time.Time, error) {
+	now := clock()
+	if m := sincePattern.FindStringSubmatch(input); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "d":
+			now = now.AddDate(0, 0, -n)
+		case "w":
+			now = now.AddDate(0, 0, -7*n)
+		case "m":
+			now = now.AddDate(0, -n, 0)
+		case "y":
+			now = now.AddDate(-n, 0, 0)
+		}
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), nil
+	}
+	t, err := ParseDate(input, clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a span such as 30d or a date, got %q", input)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+}
@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlow(t *testing.T) {
+	now := fixedClock()
+	day := 24 * time.Hour
+	tasks := []Task{
+		{ID: 1, CreatedAt: now.Add(-5 * day)},
+		{ID: 2, CreatedAt: now.Add(-3 * day), Completed: true, CompletedAt: now.Add(-2 * day)},
+		{ID: 3, CreatedAt: now.Add(-2 * day), Completed: true, CompletedAt: now},
+		{ID: 4, CreatedAt: now.Add(time.Hour)},
+	}
+	points := Flow(tasks, now.Add(-3*day), now)
+	want := []FlowPoint{
+		{Created: 2, Completed: 0, Open: 2},
+		{Created: 3, Completed: 1, Open: 2},
+		{Created: 3, Completed: 1, Open: 2},
+		{Created: 4, Completed: 2, Open: 2}, // counted at the end of today
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i, point := range points {
+		if point.Created != want[i].Created || point.Completed != want[i].Completed || point.Open != want[i].Open {
+			t.Errorf("day %s = %+v, want %+v", point.Day.Format("Jan 2"), point, want[i])
+		}
+	}
+	if got := points[0].Day.Format("2006-01-02 15:04"); got != "2025-03-30 00:00" {
+		t.Errorf("first day = %s, want 2025-03-30 00:00", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	testCases := []struct {
+		input, want string
+	}{
+		{"30d", "2025-03-03"},
+		{"2w", "2025-03-19"},
+		{"1m", "2025-03-02"},
+		{"1y", "2024-04-02"},
+		{"2025-03-15", "2025-03-15"},
+		{"yesterday", "2025-04-01"},
+	}
+	for _, tc := range testCases {
+		got, err := ParseSince(tc.input, fixedClock)
+		if err != nil {
+			t.Errorf("ParseSince(%q): %v", tc.input, err)
+			continue
+		}
+		if got.Format("2006-01-02 15:04") != tc.want+" 00:00" {
+			t.Errorf("ParseSince(%q) = %s, want %s 00:00", tc.input, got, tc.want)
+		}
+	}
+	if _, err := ParseSince("a while", fixedClock); err == nil {
+		t.Error("ParseSince(\"a while\") succeeded")
+	}
+}
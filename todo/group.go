@@ -0,0 +1,118 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Group is a run of tasks sharing a value, as shown under one heading.
+type Group struct {
+	Key   string // the shared value, empty for tasks without one
+	Label string
+	Tasks []Task
+}
+
+// GroupTasks splits tasks by status, tag, due-week, parent or a custom
+// field, keeping their order within each group. A task with several tags
+// appears under each of them. Groups for tasks without a value come last.
+// A parent task is how r2d2 keeps a list, so "list" groups by parent too.
+// Weeks start on the config's week_start day in its timezone.
+func GroupTasks(tasks []Task, by string, ctx Context) ([]Group, error) {
+	var keyOf func(t Task) []string
+	var label func(key string) string
+	var less func(a, b string) bool
+	none := "(no " + by + ")"
+
+	switch by {
+	case "status":
+		keyOf = func(t Task) []string {
+			if t.Completed {
+				return []string{"completed"}
+			}
+			return []string{"pending"}
+		}
+		label = func(key string) string {
+			if key == "completed" {
+				return "Completed"
+			}
+			return "Pending"
+		}
+		less = func(a, b string) bool { return a == "pending" && b != "pending" }
+	case "tag", "tags":
+		none = "(no tag)"
+		keyOf = func(t Task) []string {
+			if len(t.Tags) == 0 {
+				return []string{""}
+			}
+			return t.Tags
+		}
+		label = func(key string) string { return "+" + key }
+		less = func(a, b string) bool { return a < b }
+	case "due-week":
+		none = "(no due date)"
+		keyOf = func(t Task) []string {
+			if t.Due.IsZero() {
+				return []string{""}
+			}
+			return []string{StartOfWeekOn(t.Due.In(ctx.Config.Location()), ctx.Config.FirstWeekday()).Format("2006-01-02")}
+		}
+		label = func(key string) string {
+			start, _ := time.Parse("2006-01-02", key)
+			return fmt.Sprintf("Week of %s", start.Format("Mon Jan 2, 2006"))
+		}
+		less = func(a, b string) bool { return a < b }
+	case "parent", "list":
+		keyOf = func(t Task) []string {
+			if t.ParentID == 0 {
+				return []string{""}
+			}
+			return []string{strconv.Itoa(t.ParentID)}
+		}
+		label = func(key string) string { return "Subtasks of " + key }
+		less = func(a, b string) bool {
+			x, _ := strconv.Atoi(a)
+			y, _ := strconv.Atoi(b)
+			return x < y
+		}
+	default:
+		def, ok := ctx.Config.Field(by)
+		if !ok {
+			return nil, fmt.Errorf("unknown group %q (use status, tag, due-week, parent or list, or a custom field)", by)
+		}
+		keyOf = func(t Task) []string { return []string{t.Fields[def.Name]} }
+		label = func(key string) string { return def.Name + ": " + key }
+		less = func(a, b string) bool { return def.Compare(a, b) < 0 }
+	}
+
+	groups := []Group{}
+	index := map[string]int{}
+	for _, task := range tasks {
+		for _, key := range keyOf(task) {
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, Group{Key: key})
+			}
+			groups[i].Tasks = append(groups[i].Tasks, task)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Key, groups[j].Key
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return less(a, b)
+	})
+	for i := range groups {
+		if groups[i].Key == "" {
+			groups[i].Label = none
+		} else {
+			groups[i].Label = label(groups[i].Key)
+		}
+	}
+	return groups, nil
+}
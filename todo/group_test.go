@@ -0,0 +1,105 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupTasks(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, Tags: []string{"work"}, Due: now.AddDate(0, 0, 1)},
+		{ID: 2, Tags: []string{"home", "work"}, Completed: true, Due: now.AddDate(0, 0, 7), ParentID: 1},
+		{ID: 3, Due: now.AddDate(0, 0, 2), Fields: map[string]string{"size": "L"}, ParentID: 1},
+		{ID: 4, Fields: map[string]string{"size": "S"}},
+	}
+	ctx := Context{Tasks: tasks, Config: testConfig(), Now: now}
+
+	testCases := []struct {
+		by     string
+		labels []string
+		ids    [][]int
+	}{
+		{"status", []string{"Pending", "Completed"}, [][]int{{1, 3, 4}, {2}}},
+		{"tag", []string{"+home", "+work", "(no tag)"}, [][]int{{2}, {1, 2}, {3, 4}}},
+		{"due-week", []string{"Week of Mon Mar 31, 2025", "Week of Mon Apr 7, 2025", "(no due date)"}, [][]int{{1, 3}, {2}, {4}}},
+		{"parent", []string{"Subtasks of 1", "(no parent)"}, [][]int{{2, 3}, {1, 4}}},
+		{"list", []string{"Subtasks of 1", "(no list)"}, [][]int{{2, 3}, {1, 4}}},
+		{"size", []string{"size: S", "size: L", "(no size)"}, [][]int{{4}, {3}, {1, 2}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.by, func(t *testing.T) {
+			groups, err := GroupTasks(tasks, tc.by, ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels, ids := []string{}, [][]int{}
+			for _, g := range groups {
+				labels = append(labels, g.Label)
+				groupIDs := []int{}
+				for _, task := range g.Tasks {
+					groupIDs = append(groupIDs, task.ID)
+				}
+				ids = append(ids, groupIDs)
+			}
+			if !reflect.DeepEqual(labels, tc.labels) || !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("got %v %v, want %v %v", labels, ids, tc.labels, tc.ids)
+			}
+		})
+	}
+
+	sunday := ctx
+	sunday.Config.WeekStart = "sunday"
+	groups, err := GroupTasks(tasks, "due-week", sunday)
+	if err != nil || len(groups) != 3 || groups[0].Label != "Week of Sun Mar 30, 2025" || groups[1].Label != "Week of Sun Apr 6, 2025" {
+		t.Errorf("weeks starting on Sunday: %+v, %v", groups, err)
+	}
+
+	if _, err := GroupTasks(tasks, "colour", ctx); err == nil {
+		t.Error("unknown group should fail")
+	}
+}
+
+func TestSortTasksMultipleKeys(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 6, Priority: "L", Due: now.AddDate(0, 0, 1)},
+		{ID: 2, Priority: "H"},
+		{ID: 4, Priority: "H", Due: now.AddDate(0, 0, 3)},
+		{ID: 1, Due: now.AddDate(0, 0, 1)},
+		{ID: 5, Priority: "H", Due: now.AddDate(0, 0, 1)},
+	}
+	ctx := Context{Tasks: tasks, Now: now}
+	order := func() []int {
+		ids := []int{}
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	testCases := []struct {
+		spec string
+		want []int
+	}{
+		{"", []int{1, 2, 4, 5, 6}},
+		{"priority", []int{2, 4, 5, 6, 1}},
+		{"priority:asc", []int{6, 2, 4, 5, 1}},
+		{"priority,due", []int{5, 4, 2, 6, 1}},
+		{"due:desc,priority", []int{4, 5, 6, 1, 2}},
+		{"due, id:desc", []int{6, 5, 1, 4, 2}},
+	}
+	for _, tc := range testCases {
+		if err := SortTasks(tasks, tc.spec, ctx); err != nil {
+			t.Fatalf("SortTasks(%q): %v", tc.spec, err)
+		}
+		if got := order(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SortTasks(%q) = %v, want %v", tc.spec, got, tc.want)
+		}
+	}
+
+	if err := SortTasks(tasks, "due:sideways", ctx); err == nil {
+		t.Error("bad direction should fail")
+	}
+}
@@ -0,0 +1,308 @@
+package todo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryEntry records one task as it was before and after a command
+// changed it. Every entry of the same command shares its Op number. A
+// task the command added has no Before, one it deleted has no After.
+type HistoryEntry struct {
+	Op      int
+	At      time.Time
+	Command string
+	Undoes  int // for an undo, the Op it reverted
+	TaskID  int
+	Before  *Task
+	After   *Task
+}
+
+// FieldChange is one field a command changed, rendered for display.
+type FieldChange struct {
+	Field    string
+	Old, New string
+}
+
+// CloneTasks copies tasks deeply enough that changing the copy's slices
+// and custom fields leaves the originals alone.
+func CloneTasks(tasks []Task) []Task {
+	clones := make([]Task, len(tasks))
+	for i, t := range tasks {
+		t.Tags = append([]string(nil), t.Tags...)
+		t.DependsOn = append([]int(nil), t.DependsOn...)
+		t.Notes = append([]Note(nil), t.Notes...)
+		t.TimeLog = append([]Interval(nil), t.TimeLog...)
+		if t.Fields != nil {
+			fields := map[string]string{}
+			for name, value := range t.Fields {
+				fields[name] = value
+			}
+			t.Fields = fields
+		}
+		clones[i] = t
+	}
+	return clones
+}
+
+// RecordHistory appends what changed between two versions of the task
+// list to the history file as one operation. Secret descriptions stay
+// encrypted in it. Nothing is written when nothing changed.
+func RecordHistory(filename, command string, undoes int, before, after []Task, now time.Time) error {
+	history, err := LoadHistory(filename)
+	if err != nil {
+		return err
+	}
+	op := 1
+	if len(history) > 0 {
+		op = history[len(history)-1].Op + 1
+	}
+
+	old := map[int]Task{}
+	for _, t := range before {
+		old[t.ID] = t
+	}
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, t := range append(append([]Task{}, before...), after...) {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			ids = append(ids, t.ID)
+		}
+	}
+	sort.Ints(ids)
+
+	records := [][]string{}
+	for _, id := range ids {
+		b, a := "", ""
+		if t, ok := old[id]; ok {
+			b = encodeRecord(taskRecord(t))
+		}
+		if i := indexOf(after, id); i >= 0 {
+			a = encodeRecord(taskRecord(after[i]))
+		}
+		if a == b {
+			continue
+		}
+		records = append(records, []string{
+			strconv.Itoa(op), now.Format(time.RFC3339), command, formatOptionalInt(undoes), strconv.Itoa(id), b, a,
+		})
+	}
+	return appendHistory(filename, records)
+}
+
+// AppendHistory adds entries to the history file as they are, keeping
+// their Op numbers. It restores the history of an exported store.
+func AppendHistory(filename string, entries []HistoryEntry) error {
+	records := [][]string{}
+	for _, e := range entries {
+		b, a := "", ""
+		if e.Before != nil {
+			b = encodeRecord(taskRecord(*e.Before))
+		}
+		if e.After != nil {
+			a = encodeRecord(taskRecord(*e.After))
+		}
+		records = append(records, []string{
+			strconv.Itoa(e.Op), e.At.Format(time.RFC3339), e.Command, formatOptionalInt(e.Undoes), strconv.Itoa(e.TaskID), b, a,
+		})
+	}
+	return appendHistory(filename, records)
+}
+
+func appendHistory(filename string, records [][]string) error {
+	if len(records) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.New("failed to open history")
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.WriteAll(records)
+	if err := writer.Error(); err != nil {
+		return errors.New("failed to write history")
+	}
+	return nil
+}
+
+// LoadHistory reads the history file, oldest entry first. A missing file
+// is an empty history.
+func LoadHistory(filename string) ([]HistoryEntry, error) {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to open history")
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 7
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+	entries := []HistoryEntry{}
+	for n, record := range records {
+		entry, err := parseHistoryRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("history line %d: %v", n+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func parseHistoryRecord(record []string) (HistoryEntry, error) {
+	var entry HistoryEntry
+	var err error
+	if entry.Op, err = strconv.Atoi(record[0]); err != nil {
+		return entry, err
+	}
+	if entry.At, err = time.Parse(time.RFC3339, record[1]); err != nil {
+		return entry, err
+	}
+	entry.Command = record[2]
+	if entry.Undoes, err = parseOptionalInt(record[3]); err != nil {
+		return entry, err
+	}
+	if entry.TaskID, err = strconv.Atoi(record[4]); err != nil {
+		return entry, err
+	}
+	if entry.Before, err = decodeTask(record[5]); err != nil {
+		return entry, err
+	}
+	if entry.After, err = decodeTask(record[6]); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// encodeRecord packs a task record into a single CSV field.
+func encodeRecord(record []string) string {
+	var b strings.Builder
+	writer := csv.NewWriter(&b)
+	writer.Write(record)
+	writer.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func decodeTask(s string) (*Task, error) {
+	if s == "" {
+		return nil, nil
+	}
+	reader := csv.NewReader(strings.NewReader(s))
+	reader.FieldsPerRecord = -1
+	record, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// LastUndoable returns the most recent operation that has not been undone
+// and is not itself an undo, or 0 when there is none.
+func LastUndoable(history []HistoryEntry) int {
+	undone := map[int]bool{}
+	for _, entry := range history {
+		if entry.Undoes != 0 {
+			undone[entry.Undoes] = true
+		}
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		if entry.Undoes == 0 && !undone[entry.Op] {
+			return entry.Op
+		}
+	}
+	return 0
+}
+
+// Undo puts the tasks an operation touched back the way they were. Unless
+// force is set it refuses when any of them has changed since, so later
+// work is not silently thrown away.
+func Undo(tasks []Task, history []HistoryEntry, op int, force bool) ([]Task, error) {
+	entries := []HistoryEntry{}
+	for _, entry := range history {
+		if entry.Op == op {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("operation %d not found", op)
+	}
+
+	restored := CloneTasks(tasks)
+	for _, entry := range entries {
+		i := indexOf(restored, entry.TaskID)
+		if !force {
+			current := ""
+			if i >= 0 {
+				current = encodeRecord(taskRecord(restored[i]))
+			}
+			expected := ""
+			if entry.After != nil {
+				expected = encodeRecord(taskRecord(*entry.After))
+			}
+			if current != expected {
+				return nil, fmt.Errorf("task %d has changed since", entry.TaskID)
+			}
+		}
+		switch {
+		case entry.Before == nil && i >= 0:
+			restored = append(restored[:i], restored[i+1:]...)
+		case entry.Before != nil && i >= 0:
+			restored[i] = *entry.Before
+		case entry.Before != nil:
+			restored = append(restored, *entry.Before)
+		}
+	}
+	sort.SliceStable(restored, func(a, b int) bool { return restored[a].ID < restored[b].ID })
+	return restored, nil
+}
+
+// DiffTask lists the fields that differ between two versions of a task,
+// with secret descriptions left encrypted.
+func DiffTask(before, after Task, cfg Config) []FieldChange {
+	changes := []FieldChange{}
+	if before.Completed != after.Completed {
+		changes = append(changes, FieldChange{"status", taskStatus(before), taskStatus(after)})
+	}
+	for _, name := range EditableFields(cfg) {
+		if name == "description" && (before.Encrypted || after.Encrypted) {
+			if before.Description != after.Description {
+				changes = append(changes, FieldChange{name, "[ENCRYPTED]", "[ENCRYPTED]"})
+			}
+			continue
+		}
+		old, _ := FieldValue(before, name)
+		updated, _ := FieldValue(after, name)
+		if old != updated {
+			changes = append(changes, FieldChange{name, old, updated})
+		}
+	}
+	if len(after.Notes) != len(before.Notes) {
+		changes = append(changes, FieldChange{"notes", strconv.Itoa(len(before.Notes)), strconv.Itoa(len(after.Notes))})
+	}
+	return changes
+}
+
+func taskStatus(t Task) string {
+	if t.Completed {
+		return "completed"
+	}
+	return "pending"
+}
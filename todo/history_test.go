@@ -0,0 +1,101 @@
+package todo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryUndo(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.csv")
+	now := fixedClock()
+	cfg := testConfig()
+
+	original := []Task{
+		{ID: 1, Description: "Release", CreatedAt: now, Tags: []string{"work"}},
+		{ID: 2, Description: "Write notes", CreatedAt: now, Notes: []Note{{At: now, Text: "draft, \"first\""}, {At: now, Text: "second"}}},
+	}
+
+	// Modify task 1, then delete task 2 and add task 3
+	modified := CloneTasks(original)
+	modified[0].Priority = "H"
+	modified[0].Tags = append(modified[0].Tags, "urgent")
+	if original[0].HasTag("urgent") {
+		t.Fatal("CloneTasks shares tags with the original")
+	}
+	if err := RecordHistory(filename, "modify", 0, original, modified, now); err != nil {
+		t.Fatalf("RecordHistory: %v", err)
+	}
+	replaced := []Task{modified[0], {ID: 3, Description: "Ship", CreatedAt: now}}
+	if err := RecordHistory(filename, "delete", 0, modified, replaced, now); err != nil {
+		t.Fatalf("RecordHistory: %v", err)
+	}
+	if err := RecordHistory(filename, "modify", 0, replaced, replaced, now); err != nil {
+		t.Fatalf("RecordHistory: %v", err)
+	}
+
+	history, err := LoadHistory(filename)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history has %d entries, want 3 (no-op changes are not recorded)", len(history))
+	}
+	if diff := DiffTask(*history[0].Before, *history[0].After, cfg); len(diff) != 2 ||
+		diff[0] != (FieldChange{"priority", "", "H"}) || diff[1] != (FieldChange{"tags", "work", "work, urgent"}) {
+		t.Errorf("DiffTask = %+v", diff)
+	}
+
+	op := LastUndoable(history)
+	if op != 2 {
+		t.Fatalf("LastUndoable = %d, want 2", op)
+	}
+	restored, err := Undo(replaced, history, op, false)
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if !sameRecords(restored, modified) {
+		t.Errorf("Undo = %+v, want %+v", restored, modified)
+	}
+	if err := RecordHistory(filename, "undo", op, replaced, restored, now); err != nil {
+		t.Fatalf("RecordHistory: %v", err)
+	}
+
+	history, _ = LoadHistory(filename)
+	if op := LastUndoable(history); op != 1 {
+		t.Fatalf("LastUndoable after an undo = %d, want 1", op)
+	}
+	changed := CloneTasks(restored)
+	changed[0].Priority = "L"
+	if _, err := Undo(changed, history, 1, false); err == nil || !strings.Contains(err.Error(), "task 1 has changed since") {
+		t.Errorf("Undo over a later change: err = %v", err)
+	}
+	forced, err := Undo(changed, history, 1, true)
+	if err != nil || !sameRecords(forced, original) {
+		t.Errorf("forced Undo = %+v, %v; want the original tasks", forced, err)
+	}
+}
+
+func TestDiffTaskKeepsSecretsEncrypted(t *testing.T) {
+	before := Task{ID: 1, Description: "c2VjcmV0", Encrypted: true}
+	after := before
+	after.Description = "b3RoZXI="
+	diff := DiffTask(before, after, Config{})
+	if len(diff) != 1 || diff[0] != (FieldChange{"description", "[ENCRYPTED]", "[ENCRYPTED]"}) {
+		t.Errorf("DiffTask = %+v", diff)
+	}
+}
+
+// sameRecords compares tasks as they are stored, ignoring differences such
+// as time zone names that do not survive a save.
+func sameRecords(a, b []Task) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if encodeRecord(taskRecord(a[i])) != encodeRecord(taskRecord(b[i])) {
+			return false
+		}
+	}
+	return true
+}
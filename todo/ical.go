@@ -0,0 +1,434 @@
+package todo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icsDate     = "20060102"
+	icsDateTime = "20060102T150405"
+	icsUTC      = "20060102T150405Z"
+	icsNoteTime = "2006-01-02 15:04"
+)
+
+// icsUIDField keeps the UID of an imported VTODO so exporting the task
+// again gives calendar clients the same item.
+const icsUIDField = "ical_uid"
+
+// WriteICS writes tasks as an RFC 5545 calendar of VTODO components: UID,
+// SUMMARY, STATUS, DUE, COMPLETED and RRULE, plus CREATED, DTSTART for the
+// scheduled date, PRIORITY, CATEGORIES for tags and DESCRIPTION for notes.
+// A recurring task without a scheduled date starts on its due date, or on
+// the day it was created, since clients expand the rule from DTSTART.
+// Whole days are written as dates and times in UTC. Secret tasks must be
+// decrypted first.
+func WriteICS(w io.Writer, tasks []Task, now time.Time) error {
+	b := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeICSLine(b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//R2-D2//r2d2//EN")
+	for _, t := range tasks {
+		line("BEGIN", "VTODO")
+		uid := t.Fields[icsUIDField]
+		if uid == "" {
+			uid = fmt.Sprintf("r2d2-%d-%d@r2d2", t.ID, t.CreatedAt.Unix())
+		}
+		line("UID", escapeICSText(uid))
+		line("DTSTAMP", now.UTC().Format(icsUTC))
+		line("CREATED", t.CreatedAt.UTC().Format(icsUTC))
+		line("SUMMARY", escapeICSText(t.Description))
+		if t.Completed {
+			line("STATUS", "COMPLETED")
+			line("COMPLETED", t.CompletedAt.UTC().Format(icsUTC))
+		} else {
+			line("STATUS", "NEEDS-ACTION")
+		}
+		if p := icsPriority(t.Priority); p != 0 {
+			line("PRIORITY", strconv.Itoa(p))
+		}
+		start := t.Scheduled
+		if start.IsZero() && t.Recur != "" {
+			start = t.Due
+			if start.IsZero() {
+				start = t.CreatedAt
+			}
+		}
+		if !start.IsZero() {
+			writeICSLine(b, "DTSTART"+formatICSTime(start))
+		}
+		if !t.Due.IsZero() {
+			writeICSLine(b, "DUE"+formatICSTime(t.Due))
+		}
+		if t.Recur != "" {
+			line("RRULE", icsRRule(t.Recur, start))
+		}
+		if len(t.Tags) > 0 {
+			tags := []string{}
+			for _, tag := range t.Tags {
+				tags = append(tags, escapeICSText(tag))
+			}
+			line("CATEGORIES", strings.Join(tags, ","))
+		}
+		if len(t.Notes) > 0 {
+			notes := []string{}
+			for _, note := range t.Notes {
+				notes = append(notes, "["+note.At.Format(icsNoteTime)+"] "+note.Text)
+			}
+			line("DESCRIPTION", escapeICSText(strings.Join(notes, "\n")))
+		}
+		line("END", "VTODO")
+	}
+	line("END", "VCALENDAR")
+	return b.Flush()
+}
+
+// formatICSTime renders the parameters and value of a date property, from
+// the colon on: whole days as dates, anything else in UTC.
+func formatICSTime(t time.Time) string {
+	if t.Equal(EndOfDay(t)) {
+		return ";VALUE=DATE:" + t.Format(icsDate)
+	}
+	return ":" + t.UTC().Format(icsUTC)
+}
+
+// icsRRule writes UNTIL as a date when the rule starts on a date, as RFC
+// 5545 asks: the last day whose occurrence falls on or before it.
+func icsRRule(recur string, start time.Time) string {
+	rule, err := ParseRecurrence(recur)
+	if err != nil || rule.Until.IsZero() || !start.Equal(EndOfDay(start)) {
+		return recur
+	}
+	until := rule.Until.In(start.Location())
+	if EndOfDay(until).After(rule.Until) {
+		until = until.AddDate(0, 0, -1)
+	}
+	rule.Until = time.Time{}
+	return rule.String() + ";UNTIL=" + until.Format(icsDate)
+}
+
+// writeICSLine ends lines with CRLF and folds them after 75 octets,
+// without splitting a UTF-8 sequence.
+func writeICSLine(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74
+	}
+	w.WriteString(s + "\r\n")
+}
+
+var icsUntilDate = regexp.MustCompile(`(?:^|;)UNTIL=(\d{8})(?:;|$)`)
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func escapeICSText(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+func unescapeICSText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitICSList splits a comma-separated TEXT list, honouring escaped commas.
+func splitICSList(s string) []string {
+	parts := []string{}
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, unescapeICSText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, unescapeICSText(s[start:]))
+}
+
+// icsPriority maps H, M and L onto the 1-9 scale, where 1 is highest and
+// 0 means none.
+func icsPriority(p string) int {
+	switch p {
+	case "H":
+		return 1
+	case "M":
+		return 5
+	case "L":
+		return 9
+	}
+	return 0
+}
+
+// icsProperty is one unfolded content line.
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// parseICSLine splits "NAME;PARAM=VALUE;...:value". Quoted parameter
+// values may contain colons and semicolons.
+func parseICSLine(line string) (icsProperty, error) {
+	p := icsProperty{Params: map[string]string{}}
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("expected NAME:value, got %q", line)
+	}
+	p.Value = line[colon+1:]
+	head := line[:colon]
+
+	parts := []string{}
+	start := 0
+	quoted = false
+	for i, r := range head {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ';' && !quoted {
+			parts = append(parts, head[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, head[start:])
+	p.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return p, nil
+}
+
+// parseICSTime reads a DATE or DATE-TIME value. Dates are whole days,
+// ending at the last second like the dates "add --due" takes. Times ending
+// in Z are UTC, those with a TZID in that zone and floating ones in loc;
+// zones Go does not know (such as Windows names) fall back to loc.
+func parseICSTime(p icsProperty, loc *time.Location) (time.Time, error) {
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len(icsDate) {
+		t, err := time.ParseInLocation(icsDate, p.Value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", p.Value)
+		}
+		return EndOfDay(t), nil
+	}
+	if strings.HasSuffix(p.Value, "Z") {
+		t, err := time.Parse(icsUTC, p.Value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", p.Value)
+		}
+		return t.In(loc), nil
+	}
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation(icsDateTime, p.Value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", p.Value)
+	}
+	return t, nil
+}
+
+// ReadICS reads the VTODO components of an iCalendar file into tasks
+// without IDs; events and other components are skipped. Floating times
+// are read in the clock's location. Errors name the line they are on; all
+// of them are reported at once.
+func ReadICS(r io.Reader, clock Clock) ([]Task, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	loc := clock().Location()
+	tasks := []Task{}
+	errs := []error{}
+	var task *Task
+	var todoLine int
+	depth := 0 // components nested inside the current VTODO, such as VALARM
+
+	for _, l := range lines {
+		p, err := parseICSLine(l.text)
+		if err != nil {
+			// Only what becomes a task has to make sense
+			if task != nil {
+				errs = append(errs, &EditError{Line: l.n, Msg: err.Error()})
+			}
+			continue
+		}
+		value := strings.ToUpper(p.Value)
+		switch {
+		case p.Name == "BEGIN" && value == "VTODO" && task == nil:
+			task = &Task{CreatedAt: clock()}
+			todoLine = l.n
+			continue
+		case task == nil:
+			continue
+		case p.Name == "BEGIN":
+			depth++
+			continue
+		case p.Name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case p.Name == "END" && value == "VTODO":
+			if task.Description == "" {
+				errs = append(errs, &EditError{Line: todoLine, Msg: "task has no SUMMARY"})
+			} else {
+				if task.Completed && task.CompletedAt.IsZero() {
+					task.CompletedAt = clock()
+				}
+				// A recurring task's DTSTART may only be where its series
+				// starts, as WriteICS puts it, and not a scheduled date
+				if task.Recur != "" && (task.Scheduled.Equal(task.Due) || task.Scheduled.Equal(task.CreatedAt)) {
+					task.Scheduled = time.Time{}
+				}
+				tasks = append(tasks, *task)
+			}
+			task = nil
+			continue
+		}
+		if err := setICSProperty(task, p, loc, clock); err != nil {
+			errs = append(errs, &EditError{Line: l.n, Msg: err.Error()})
+		}
+	}
+	if task != nil {
+		errs = append(errs, &EditError{Line: todoLine, Msg: "VTODO is never closed"})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return tasks, nil
+}
+
+func setICSProperty(t *Task, p icsProperty, loc *time.Location, clock Clock) error {
+	var err error
+	switch p.Name {
+	case "UID":
+		if t.Fields == nil {
+			t.Fields = map[string]string{}
+		}
+		t.Fields[icsUIDField] = unescapeICSText(p.Value)
+	case "SUMMARY":
+		t.Description = strings.Join(strings.Fields(unescapeICSText(p.Value)), " ")
+	case "STATUS":
+		// Cancelled tasks are done with as far as a todo list goes
+		switch strings.ToUpper(p.Value) {
+		case "COMPLETED", "CANCELLED":
+			t.Completed = true
+		}
+	case "COMPLETED":
+		t.Completed = true
+		t.CompletedAt, err = parseICSTime(p, loc)
+	case "CREATED":
+		t.CreatedAt, err = parseICSTime(p, loc)
+	case "DUE":
+		t.Due, err = parseICSTime(p, loc)
+	case "DTSTART":
+		t.Scheduled, err = parseICSTime(p, loc)
+	case "RRULE":
+		var rule Recurrence
+		if rule, err = ParseRecurrence(p.Value); err == nil {
+			// An UNTIL date includes that whole day
+			if m := icsUntilDate.FindStringSubmatch(strings.ToUpper(p.Value)); m != nil {
+				day, _ := time.ParseInLocation(icsDate, m[1], loc)
+				rule.Until = EndOfDay(day)
+			}
+			t.Recur = rule.String()
+		}
+	case "PRIORITY":
+		var n int
+		if n, err = strconv.Atoi(strings.TrimSpace(p.Value)); err == nil {
+			switch {
+			case n >= 1 && n <= 4:
+				t.Priority = "H"
+			case n == 5:
+				t.Priority = "M"
+			case n >= 6 && n <= 9:
+				t.Priority = "L"
+			}
+		}
+	case "CATEGORIES":
+		for _, category := range splitICSList(p.Value) {
+			if tag, ok := NormalizeTag(strings.ReplaceAll(strings.TrimSpace(category), " ", "-")); ok {
+				t.Tags = AddTags(t.Tags, tag)
+			}
+		}
+	case "DESCRIPTION":
+		for _, text := range strings.Split(unescapeICSText(p.Value), "\n") {
+			note := Note{At: clock(), Text: strings.TrimSpace(text)}
+			if stamp, rest, ok := strings.Cut(note.Text, "] "); ok && strings.HasPrefix(stamp, "[") {
+				if at, err := time.ParseInLocation(icsNoteTime, stamp[1:], loc); err == nil {
+					note.At, note.Text = at, rest
+				}
+			}
+			if note.Text != "" {
+				t.Notes = append(t.Notes, note)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", p.Name, err)
+	}
+	return nil
+}
+
+type icsLine struct {
+	n    int // where the line starts in the file
+	text string
+}
+
+// unfoldICS joins folded lines, accepting bare LF line ends as well.
+func unfoldICS(r io.Reader) ([]icsLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	lines := []icsLine{}
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, icsLine{n, text})
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,161 @@
+package todo
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites the file when
+// the tests run with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func icsTestTasks() []Task {
+	now := fixedClock()
+	return []Task{
+		{ID: 1, Description: "Pay rent; then file receipts, quickly", CreatedAt: now.Add(-72 * time.Hour),
+			Due: EndOfDay(now.AddDate(0, 0, 3)), Priority: "H", Tags: []string{"home", "@phone"},
+			Notes: []Note{{At: now.Add(-time.Hour), Text: `ask about the C:\ deposit`}, {At: now, Text: "paid"}}},
+		{ID: 2, Description: "Stand-up", CreatedAt: now.Add(-48 * time.Hour),
+			Due: time.Date(2025, time.April, 7, 9, 30, 0, 0, now.Location()), Recur: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{ID: 3, Description: "Write the quarterly report covering revenue, hiring, the roadmap and the ünïcödé budget", CreatedAt: now.Add(-24 * time.Hour),
+			Completed: true, CompletedAt: now, Scheduled: EndOfDay(now.AddDate(0, 0, -1)), Priority: "L"},
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteICS(&out, icsTestTasks(), fixedClock()); err != nil {
+		t.Fatalf("WriteICS: %v", err)
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line %d is %d octets long: %q", i+1, len(line), line)
+		}
+	}
+	checkGolden(t, "export.ics", out.Bytes())
+
+	tasks, err := ReadICS(bytes.NewReader(out.Bytes()), fixedClock)
+	if err != nil {
+		t.Fatalf("ReadICS: %v", err)
+	}
+	var again bytes.Buffer
+	if err := WriteICS(&again, tasks, fixedClock()); err != nil {
+		t.Fatalf("WriteICS: %v", err)
+	}
+	if again.String() != out.String() {
+		t.Errorf("round trip =\n%s\nwant\n%s", again.String(), out.String())
+	}
+	if tasks[0].Description != icsTestTasks()[0].Description || tasks[0].Notes[0].Text != `ask about the C:\ deposit` {
+		t.Errorf("text not unescaped: %+v", tasks[0])
+	}
+}
+
+// testdata/import.ics is in the style of other calendar clients: folded
+// lines, TZID parameters, a Windows zone name, events and alarms.
+func TestReadICS(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "import.ics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	tasks, err := ReadICS(file, fixedClock)
+	if err != nil {
+		t.Fatalf("ReadICS: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("read %d tasks, want 3", len(tasks))
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	review := tasks[0]
+	if review.Description != "Review the Q2 plan, part 1; bring slides" || review.Priority != "H" ||
+		!review.Due.Equal(time.Date(2025, time.April, 8, 14, 0, 0, 0, berlin)) ||
+		strings.Join(review.Tags, " ") != "work project-x" || review.Fields[icsUIDField] != "040000008200E00074C5B7101A82E008@example.com" {
+		t.Errorf("review = %+v", review)
+	}
+	if len(review.Notes) != 1 || review.Notes[0].Text != "Agenda:" || !review.Notes[0].At.Equal(fixedClock()) {
+		t.Errorf("review notes = %+v", review.Notes)
+	}
+	sync := tasks[1]
+	if !sync.Due.Equal(time.Date(2025, time.April, 9, 10, 0, 0, 0, fixedClock().Location())) || sync.Recur != "FREQ=WEEKLY;BYDAY=WE" {
+		t.Errorf("sync = %+v", sync)
+	}
+	old := tasks[2]
+	if !old.Completed || !old.CompletedAt.Equal(fixedClock()) || !old.Due.Equal(EndOfDay(time.Date(2025, time.March, 1, 0, 0, 0, 0, fixedClock().Location()))) {
+		t.Errorf("cancelled = %+v", old)
+	}
+
+	var out bytes.Buffer
+	if err := WriteICS(&out, tasks, fixedClock()); err != nil {
+		t.Fatalf("WriteICS: %v", err)
+	}
+	checkGolden(t, "import.golden.ics", out.Bytes())
+}
+
+// A recurring task without a scheduled date starts on its due date, and
+// an UNTIL under a date start is the last day that still has an occurrence.
+func TestWriteICSRecurrenceStart(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, Description: "Water plants", CreatedAt: now, Due: EndOfDay(now), Recur: "FREQ=DAILY;UNTIL=20250405T133000Z"},
+		{ID: 2, Description: "Stretch", CreatedAt: now, Recur: "FREQ=DAILY"},
+	}
+	var out bytes.Buffer
+	if err := WriteICS(&out, tasks, now); err != nil {
+		t.Fatalf("WriteICS: %v", err)
+	}
+	for _, want := range []string{
+		"DTSTART;VALUE=DATE:20250402\r\nDUE;VALUE=DATE:20250402\r\nRRULE:FREQ=DAILY;UNTIL=20250404\r\n",
+		"DTSTART:20250402T133000Z\r\nRRULE:FREQ=DAILY\r\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("export lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	read, err := ReadICS(bytes.NewReader(out.Bytes()), fixedClock)
+	if err != nil {
+		t.Fatalf("ReadICS: %v", err)
+	}
+	if !read[0].Scheduled.IsZero() || !read[1].Scheduled.IsZero() {
+		t.Errorf("the series start became a scheduled date: %+v", read)
+	}
+	if want := "FREQ=DAILY;UNTIL=20250405T025959Z"; read[0].Recur != want {
+		t.Errorf("recur = %q, want %q", read[0].Recur, want)
+	}
+}
+
+func TestReadICSErrors(t *testing.T) {
+	input := "BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:Fine\nDUE:2025-04-01\nEND:VTODO\nBEGIN:VTODO\nDUE:20250401\nEND:VTODO\nBEGIN:VTODO\n"
+	_, err := ReadICS(strings.NewReader(input), fixedClock)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"line 4: DUE: invalid time", "line 6: task has no SUMMARY", "line 9: VTODO is never closed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package todo
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSyncFilter picks the tasks a synced checklist gets when neither
+// the command nor the file names a filter.
+const DefaultSyncFilter = "status:pending"
+
+var (
+	markdownItem    = regexp.MustCompile(`^(\s*[-*+]\s+)\[([ xX])\]\s+(.*?)\s*$`)
+	markdownComment = regexp.MustCompile(`\s*<!-- r2d2:(\d+)(?:\.([0-9a-z]+))? (open|done) ([0-9a-f]{8}) -->$`)
+	markdownFilter  = regexp.MustCompile(`^<!-- r2d2 filter: (.*) -->$`)
+)
+
+// MarkdownItem is a "- [ ] text" checklist line. Lines that came from
+// r2d2 end in a hidden comment with the task ID and creation stamp and the
+// state and text they had when last synced, which is how a sync tells
+// which side changed. The stamp tells a task from a later one that reused
+// the ID of a deleted task, or from one in another store.
+type MarkdownItem struct {
+	Done  bool
+	Text  string // the description followed by the task's tags
+	ID    int    // 0 for items without the comment
+	Stamp string // of the task's creation time
+	Base  string // "open" or "done" at the last sync
+	Hash  string // of Text at the last sync
+}
+
+func parseMarkdownItem(line string) (string, MarkdownItem, bool) {
+	m := markdownItem.FindStringSubmatch(line)
+	if m == nil {
+		return "", MarkdownItem{}, false
+	}
+	item := MarkdownItem{Done: m[2] != " ", Text: m[3]}
+	if c := markdownComment.FindStringSubmatch(item.Text); c != nil {
+		item.ID, _ = strconv.Atoi(c[1])
+		item.Stamp, item.Base, item.Hash = c[2], c[3], c[4]
+		item.Text = strings.TrimSuffix(item.Text, c[0])
+	}
+	return m[1], item, true
+}
+
+// line renders the item after prefix, recording its current state as the
+// synced one.
+func (item MarkdownItem) line(prefix string) string {
+	box := "[ ]"
+	if item.Done {
+		box = "[x]"
+	}
+	return fmt.Sprintf("%s%s %s <!-- r2d2:%d.%s %s %s -->", prefix, box, item.Text, item.ID, item.Stamp, markdownState(item.Done), markdownHash(item.Text))
+}
+
+// newMarkdownItem is the item for a task as it is now.
+func newMarkdownItem(t Task) MarkdownItem {
+	return MarkdownItem{Done: t.Completed, Text: markdownText(t), ID: t.ID, Stamp: markdownStamp(t)}
+}
+
+// markdownStamp is the task's creation time in seconds, in base 36.
+func markdownStamp(t Task) string {
+	if t.CreatedAt.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.CreatedAt.Unix(), 36)
+}
+
+func markdownState(done bool) string {
+	if done {
+		return "done"
+	}
+	return "open"
+}
+
+func markdownHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:4])
+}
+
+// markdownText is how a task reads in a checklist: its description and
+// its tags as +tag words, or @context ones.
+func markdownText(t Task) string {
+	words := []string{t.Description}
+	for _, tag := range t.Tags {
+		if strings.HasPrefix(tag, "@") {
+			words = append(words, tag)
+		} else {
+			words = append(words, "+"+tag)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// splitMarkdownText is the inverse of markdownText.
+func splitMarkdownText(text string) (string, []string) {
+	kept := []string{}
+	tags := []string{}
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, "+") || strings.HasPrefix(word, "@") {
+			if tag, ok := NormalizeTag(word); ok {
+				tags = AddTags(tags, tag)
+				continue
+			}
+		}
+		kept = append(kept, word)
+	}
+	return strings.Join(kept, " "), tags
+}
+
+// WriteMarkdown writes groups of tasks as checklists, each under a
+// heading unless it has no label. Secret tasks must be decrypted first.
+func WriteMarkdown(w io.Writer, groups []Group) error {
+	b := bufio.NewWriter(w)
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if group.Label != "" {
+			b.WriteString("## " + group.Label + "\n\n")
+		}
+		for _, t := range group.Tasks {
+			item := newMarkdownItem(t)
+			b.WriteString(item.line("- ") + "\n")
+		}
+	}
+	return b.Flush()
+}
+
+// ReadMarkdown reads the checklist items of a Markdown file as new tasks
+// without IDs. Everything else in the file is ignored.
+func ReadMarkdown(r io.Reader, now time.Time) ([]Task, error) {
+	tasks := []Task{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		_, item, ok := parseMarkdownItem(scanner.Text())
+		if !ok {
+			continue
+		}
+		description, tags := splitMarkdownText(item.Text)
+		if description == "" {
+			continue
+		}
+		task := Task{Description: description, Tags: tags, CreatedAt: now}
+		if item.Done {
+			task.Completed, task.CompletedAt = true, now
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, scanner.Err()
+}
+
+// MarkdownFilter returns the filter recorded at the top of a synced file.
+func MarkdownFilter(text string) (string, bool) {
+	first, _, _ := strings.Cut(text, "\n")
+	if m := markdownFilter.FindStringSubmatch(strings.TrimSpace(first)); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// SetMarkdownFilter records the filter at the top of the file, replacing
+// the one there.
+func SetMarkdownFilter(text, filter string) string {
+	header := "<!-- r2d2 filter: " + filter + " -->"
+	if _, ok := MarkdownFilter(text); ok {
+		_, rest, _ := strings.Cut(text, "\n")
+		return header + "\n" + rest
+	}
+	return header + "\n" + text
+}
+
+// SyncChange is one change a sync made, to the file or to the store.
+type SyncChange struct {
+	ID     int
+	InFile bool
+	What   string // added, completed, reopened, updated, removed, conflict or refused
+}
+
+// SyncMarkdown reconciles a checklist with the tasks. For every item with
+// an ID, whichever side changed since the last sync wins: checking a box
+// completes the task and completing the task checks the box, and the same
+// for the text. When both changed the text, the store's wins. As with the
+// complete command, a task with open subtasks is not completed: its box is
+// unchecked again unless the same sync checks all of them. Items without
+// an ID become new tasks; items whose task was deleted are removed. Tasks
+// that include accepts and that are not in the file yet are added after
+// its last item. The rest of the file is left as it is.
+func SyncMarkdown(text string, tasks []Task, include func(Task) bool, now time.Time) (string, []Task, []SyncChange, error) {
+	tasks = CloneTasks(tasks)
+	changes := []SyncChange{}
+	trailing := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	out := []string{}
+	inFile := map[int]bool{}
+	after := -1 // where new items go: after the last one
+	type checked struct {
+		line   int
+		prefix string
+		item   MarkdownItem
+	}
+	waiting := []checked{} // checked parents whose subtasks are still open
+	for _, line := range lines {
+		prefix, item, ok := parseMarkdownItem(line)
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+
+		wait := false
+		if item.ID == 0 {
+			description, tags := splitMarkdownText(item.Text)
+			if description == "" {
+				out = append(out, line)
+				continue
+			}
+			task := Task{ID: NextID(tasks), Description: description, Tags: tags, CreatedAt: now}
+			if item.Done {
+				task.Completed, task.CompletedAt = true, now
+			}
+			tasks = append(tasks, task)
+			item.ID, item.Stamp = task.ID, markdownStamp(task)
+			changes = append(changes, SyncChange{task.ID, false, "added"})
+		} else {
+			// A stamp that doesn't match is from a task that was deleted.
+			// Items written before there were stamps go by the ID alone.
+			i := indexOf(tasks, item.ID)
+			if i >= 0 && item.Stamp != "" && item.Stamp != markdownStamp(tasks[i]) {
+				i = -1
+			}
+			if i < 0 {
+				changes = append(changes, SyncChange{item.ID, true, "removed"})
+				continue
+			}
+			if tasks[i].Encrypted {
+				out = append(out, line)
+				continue
+			}
+
+			base := item.Base == "done"
+			switch {
+			case item.Done != base && tasks[i].Completed == base:
+				what := "reopened"
+				if item.Done {
+					what = "completed"
+					// Its subtasks may be checked further down
+					if len(OpenChildren(tasks, item.ID)) > 0 {
+						wait = true
+						break
+					}
+					var err error
+					if tasks, err = completeForSync(tasks, i, now); err != nil {
+						return "", nil, nil, err
+					}
+				} else {
+					tasks[i].Completed, tasks[i].CompletedAt = false, time.Time{}
+				}
+				changes = append(changes, SyncChange{item.ID, false, what})
+			case tasks[i].Completed != base && item.Done == base:
+				item.Done = tasks[i].Completed
+				what := "reopened"
+				if item.Done {
+					what = "completed"
+				}
+				changes = append(changes, SyncChange{item.ID, true, what})
+			}
+
+			i = indexOf(tasks, item.ID)
+			stored := markdownText(tasks[i])
+			fileChanged, storeChanged := markdownHash(item.Text) != item.Hash, markdownHash(stored) != item.Hash
+			description, tags := splitMarkdownText(item.Text)
+			switch {
+			case item.Text == stored:
+			case fileChanged && !storeChanged && description != "":
+				tasks[i].Description, tasks[i].Tags = description, tags
+				changes = append(changes, SyncChange{item.ID, false, "updated"})
+			case !fileChanged || !storeChanged:
+				// The store changed, or the item lost its description
+				item.Text = stored
+				changes = append(changes, SyncChange{item.ID, true, "updated"})
+			default:
+				item.Text = stored
+				changes = append(changes, SyncChange{item.ID, true, "conflict"})
+			}
+		}
+
+		inFile[item.ID] = true
+		if wait {
+			waiting = append(waiting, checked{len(out), prefix, item})
+		}
+		out = append(out, item.line(prefix))
+		after = len(out)
+	}
+
+	// Complete the parents whose subtasks are all done now, nearest ones
+	// first, and uncheck the rest
+	for progress := true; progress; {
+		progress = false
+		for k := 0; k < len(waiting); k++ {
+			c := waiting[k]
+			if len(OpenChildren(tasks, c.item.ID)) > 0 {
+				continue
+			}
+			var err error
+			if tasks, err = completeForSync(tasks, indexOf(tasks, c.item.ID), now); err != nil {
+				return "", nil, nil, err
+			}
+			changes = append(changes, SyncChange{c.item.ID, false, "completed"})
+			waiting = append(waiting[:k], waiting[k+1:]...)
+			k--
+			progress = true
+		}
+	}
+	for _, c := range waiting {
+		c.item.Done = false
+		out[c.line] = c.item.line(c.prefix)
+		changes = append(changes, SyncChange{c.item.ID, true, "refused"})
+	}
+
+	added := []string{}
+	for _, task := range tasks {
+		if inFile[task.ID] || task.Encrypted || !include(task) {
+			continue
+		}
+		item := newMarkdownItem(task)
+		added = append(added, item.line("- "))
+		changes = append(changes, SyncChange{task.ID, true, "added"})
+	}
+	if after < 0 {
+		after = len(out)
+		if after > 0 && strings.TrimSpace(out[after-1]) != "" && len(added) > 0 {
+			out = append(out, "")
+			after++
+		}
+	}
+	out = append(out[:after], append(added, out[after:]...)...)
+
+	result := strings.Join(out, "\n")
+	if trailing || text == "" {
+		result += "\n"
+	}
+	return result, tasks, changes, nil
+}
+
+// completeForSync completes tasks[i] as the complete command does: its
+// timer stops and a recurring task's next occurrence is added.
+func completeForSync(tasks []Task, i int, now time.Time) ([]Task, error) {
+	if ActiveTimer(tasks) == i {
+		StopTimer(tasks, now)
+	}
+	tasks[i].Completed, tasks[i].CompletedAt = true, now
+	next, ok, err := NextOccurrence(tasks, tasks[i], now)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		tasks = append(tasks, next)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,193 @@
+package todo
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAndReadMarkdown(t *testing.T) {
+	groups := []Group{
+		{Label: "+home", Tasks: []Task{{ID: 1, Description: "Pay rent", Tags: []string{"home", "@phone"}}}},
+		{Label: "(no tag)", Tasks: []Task{{ID: 2, Description: "Buy milk", Completed: true}}},
+	}
+	var out bytes.Buffer
+	if err := WriteMarkdown(&out, groups); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	want := "## +home\n\n" +
+		"- [ ] Pay rent +home @phone <!-- r2d2:1.0 open " + markdownHash("Pay rent +home @phone") + " -->\n" +
+		"\n## (no tag)\n\n" +
+		"- [x] Buy milk <!-- r2d2:2.0 done " + markdownHash("Buy milk") + " -->\n"
+	if out.String() != want {
+		t.Errorf("markdown =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	tasks, err := ReadMarkdown(strings.NewReader(out.String()+"* [X] Call the bank\n- not an item\n"), fixedClock())
+	if err != nil {
+		t.Fatalf("ReadMarkdown: %v", err)
+	}
+	if len(tasks) != 3 || tasks[0].Description != "Pay rent" || !reflect.DeepEqual(tasks[0].Tags, []string{"home", "@phone"}) ||
+		!tasks[1].Completed || tasks[2].Description != "Call the bank" || !tasks[2].Completed || tasks[0].ID != 0 {
+		t.Errorf("tasks = %+v", tasks)
+	}
+}
+
+func TestSyncMarkdown(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, Description: "Pay rent", Tags: []string{"home"}},
+		{ID: 2, Description: "Buy milk"},
+		{ID: 3, Description: "Write report"},
+		{ID: 4, Description: "Plan trip"},
+		{ID: 5, Description: "Old task"},
+		{ID: 6, Description: "Renew passport"},
+	}
+	pending := func(t Task) bool { return !t.Completed }
+	first, synced, changes, err := SyncMarkdown("# TODO\n\nNotes stay.\n", tasks[:5], pending, now)
+	if err != nil {
+		t.Fatalf("SyncMarkdown: %v", err)
+	}
+	if len(changes) != 5 || !strings.HasPrefix(first, "# TODO\n\nNotes stay.\n\n- [ ] Pay rent +home <!-- r2d2:1.0 open ") {
+		t.Fatalf("first sync =\n%s\nchanges %+v", first, changes)
+	}
+	if !sameRecords(synced, tasks[:5]) {
+		t.Errorf("a first sync changed the store: %+v", synced)
+	}
+
+	// In the file: check 1, rename 3, add a line, edit 4. In the store:
+	// complete 2, rename 4 differently, delete 5, add 6.
+	file := strings.NewReplacer(
+		"- [ ] Pay rent", "- [x] Pay rent",
+		"- [ ] Write report", "- [ ] Write the report +work",
+		"- [ ] Plan trip", "- [ ] Plan the trip",
+	).Replace(first) + "- [ ] Call mom\n"
+	store := CloneTasks(tasks)
+	store[1].Completed = true
+	store[3].Description = "Plan trip to Rio"
+	store = append(store[:4], store[5])
+
+	text, updated, changes, err := SyncMarkdown(file, store, pending, now)
+	if err != nil {
+		t.Fatalf("SyncMarkdown: %v", err)
+	}
+	wantChanges := []SyncChange{
+		{1, false, "completed"},
+		{2, true, "completed"},
+		{3, false, "updated"},
+		{4, true, "conflict"},
+		{5, true, "removed"},
+		{7, false, "added"},
+		{6, true, "added"},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes = %+v\nwant %+v", changes, wantChanges)
+	}
+	for _, want := range []string{
+		"- [x] Pay rent +home <!--",
+		"- [x] Buy milk <!--",
+		"- [ ] Write the report +work <!--",
+		"- [ ] Plan trip to Rio <!--",
+		"- [ ] Call mom <!-- r2d2:7." + markdownStamp(Task{CreatedAt: now}) + " open",
+		"- [ ] Renew passport <!-- r2d2:6.0 open",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("file lacks %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Old task") {
+		t.Errorf("the deleted task is still in the file:\n%s", text)
+	}
+	i := indexOf(updated, 3)
+	if !updated[0].Completed || updated[i].Description != "Write the report" || !reflect.DeepEqual(updated[i].Tags, []string{"work"}) ||
+		updated[indexOf(updated, 7)].Description != "Call mom" {
+		t.Errorf("store = %+v", updated)
+	}
+
+	again, _, changes, err := SyncMarkdown(text, updated, pending, now)
+	if err != nil || again != text || len(changes) != 0 {
+		t.Errorf("a second sync changed things: %+v\n%s", changes, again)
+	}
+}
+
+// Checking a parent in the file completes it only when its subtasks are
+// done too, like the complete command.
+func TestSyncMarkdownOpenSubtasks(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, Description: "Move house"},
+		{ID: 2, Description: "Pack books", ParentID: 1},
+		{ID: 3, Description: "Release"},
+		{ID: 4, Description: "Tag it", ParentID: 3},
+	}
+	all := func(Task) bool { return true }
+	first, _, _, err := SyncMarkdown("", tasks, all, now)
+	if err != nil {
+		t.Fatalf("SyncMarkdown: %v", err)
+	}
+
+	// Check parent 1 alone, and parent 3 above its only subtask
+	file := strings.NewReplacer("- [ ] Move house", "- [x] Move house", "- [ ] Release", "- [x] Release", "- [ ] Tag it", "- [x] Tag it").Replace(first)
+	text, updated, changes, err := SyncMarkdown(file, tasks, all, now)
+	if err != nil {
+		t.Fatalf("SyncMarkdown: %v", err)
+	}
+	wantChanges := []SyncChange{{4, false, "completed"}, {3, false, "completed"}, {1, true, "refused"}}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes = %+v\nwant %+v", changes, wantChanges)
+	}
+	if updated[0].Completed || !updated[2].Completed || !updated[3].Completed {
+		t.Errorf("store = %+v", updated)
+	}
+	if !strings.Contains(text, "- [ ] Move house <!-- r2d2:1.0 open") || !strings.Contains(text, "- [x] Release <!-- r2d2:3.0 done") {
+		t.Errorf("file =\n%s", text)
+	}
+}
+
+// An item whose task was deleted is not matched to a later task that got
+// the same ID.
+func TestSyncMarkdownReusedID(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, Description: "alpha", CreatedAt: now.Add(-time.Hour)},
+		{ID: 2, Description: "beta task", CreatedAt: now.Add(-time.Hour)},
+	}
+	pending := func(t Task) bool { return !t.Completed }
+	first, _, _, err := SyncMarkdown("", tasks, pending, now)
+	if err != nil {
+		t.Fatalf("SyncMarkdown: %v", err)
+	}
+
+	store := []Task{tasks[0], {ID: 2, Description: "gamma unrelated", CreatedAt: now}}
+	file := strings.Replace(first, "- [ ] beta task", "- [x] beta task", 1)
+	text, updated, changes, err := SyncMarkdown(file, store, pending, now)
+	if err != nil {
+		t.Fatalf("SyncMarkdown: %v", err)
+	}
+	wantChanges := []SyncChange{{2, true, "removed"}, {2, true, "added"}}
+	if !reflect.DeepEqual(changes, wantChanges) || updated[1].Completed {
+		t.Errorf("changes = %+v, store = %+v", changes, updated)
+	}
+	if strings.Contains(text, "beta task") || !strings.Contains(text, "- [ ] gamma unrelated") {
+		t.Errorf("file =\n%s", text)
+	}
+
+	// Files from before the stamp match by ID alone
+	legacy := "- [ ] alpha <!-- r2d2:1 open " + markdownHash("alpha") + " -->\n"
+	if _, _, changes, _ := SyncMarkdown(legacy, tasks[:1], pending, now); len(changes) != 0 {
+		t.Errorf("legacy item: changes = %+v", changes)
+	}
+}
+
+func TestMarkdownFilter(t *testing.T) {
+	text := SetMarkdownFilter("# TODO\n", "tag:repo")
+	if filter, ok := MarkdownFilter(text); !ok || filter != "tag:repo" {
+		t.Errorf("filter = %q, %v", filter, ok)
+	}
+	text = SetMarkdownFilter(text, "status:pending")
+	if text != "<!-- r2d2 filter: status:pending -->\n# TODO\n" {
+		t.Errorf("text = %q", text)
+	}
+}
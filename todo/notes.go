@@ -0,0 +1,80 @@
+package todo
+
+import (
+	"strings"
+	"time"
+)
+
+// Note is a timestamped annotation on a task. On secret tasks Text holds
+// ciphertext, encrypted with the same key as the description.
+type Note struct {
+	At   time.Time
+	Text string
+}
+
+// Annotate appends a note to the task, encrypting it when the task is
+// secret.
+func Annotate(t *Task, text string, now time.Time) error {
+	text = strings.Join(strings.Fields(text), " ")
+	if t.Encrypted {
+		encrypted, err := EncryptText(text)
+		if err != nil {
+			return err
+		}
+		text = encrypted
+	}
+	t.Notes = append(t.Notes, Note{At: now, Text: text})
+	return nil
+}
+
+// MatchText reports whether query appears, case-insensitively, in the
+// task's description or notes. Secret tasks are only searched when reveal
+// is set.
+func MatchText(t Task, query string, reveal bool) bool {
+	if t.Encrypted && !reveal {
+		return false
+	}
+	query = strings.ToLower(query)
+	texts := []string{t.Description}
+	for _, note := range t.Notes {
+		texts = append(texts, note.Text)
+	}
+	for _, text := range texts {
+		if t.Encrypted {
+			decrypted, err := DecryptText(text)
+			if err != nil {
+				continue
+			}
+			text = decrypted
+		}
+		if strings.Contains(strings.ToLower(text), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNotes stores notes one per line as "<RFC 3339 time> <text>".
+func formatNotes(notes []Note) string {
+	lines := []string{}
+	for _, note := range notes {
+		lines = append(lines, note.At.Format(time.RFC3339)+" "+note.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseNotes(s string) ([]Note, error) {
+	notes := []Note{}
+	for _, line := range strings.Split(s, "\n") {
+		if line == "" {
+			continue
+		}
+		stamp, text, _ := strings.Cut(line, " ")
+		at, err := time.Parse(time.RFC3339, stamp)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, Note{At: at, Text: text})
+	}
+	return notes, nil
+}
@@ -0,0 +1,51 @@
+package todo
+
+import (
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	now := fixedClock()
+	task := Task{ID: 1, Description: "Deploy"}
+	if err := Annotate(&task, "  waiting on\ninfra  ", now); err != nil {
+		t.Fatalf("Annotate() error = %v", err)
+	}
+	if len(task.Notes) != 1 || task.Notes[0].Text != "waiting on infra" || !task.Notes[0].At.Equal(now) {
+		t.Errorf("unexpected notes %+v", task.Notes)
+	}
+}
+
+func TestAnnotateSecretTask(t *testing.T) {
+	encrypted, _ := EncryptText("Bank")
+	task := Task{ID: 1, Description: encrypted, Encrypted: true}
+	if err := Annotate(&task, "pin is 1234", fixedClock()); err != nil {
+		t.Fatalf("Annotate() error = %v", err)
+	}
+	if task.Notes[0].Text == "pin is 1234" {
+		t.Fatalf("note on a secret task was stored in plain text")
+	}
+	decrypted, err := DecryptText(task.Notes[0].Text)
+	if err != nil || decrypted != "pin is 1234" {
+		t.Errorf("DecryptText(note) = %q, %v", decrypted, err)
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	task := Task{Description: "Deploy", Notes: []Note{{At: fixedClock(), Text: "Waiting on INFRA"}}}
+	if !MatchText(task, "deploy", false) || !MatchText(task, "infra", false) {
+		t.Errorf("MatchText should find text in description and notes")
+	}
+	if MatchText(task, "database", false) {
+		t.Errorf("MatchText matched text that is not there")
+	}
+
+	secret := Task{Description: "Bank", Encrypted: true}
+	Annotate(&secret, "pin is 1234", fixedClock())
+	secret.Description, _ = EncryptText("Bank")
+	if MatchText(secret, "1234", false) {
+		t.Errorf("secret notes should not be searched while locked")
+	}
+	if !MatchText(secret, "1234", true) || !MatchText(secret, "bank", true) {
+		t.Errorf("secret task should be searchable once revealed")
+	}
+}
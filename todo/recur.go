@@ -0,0 +1,386 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is the subset of an RFC 5545 RRULE that R2-D2 understands.
+type Recurrence struct {
+	Freq       string // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonth    time.Month
+	ByMonthDay int // 1..31, or negative to count back from the end of the month
+	Count      int
+	Until      time.Time
+}
+
+var rruleDays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var freqUnits = map[string]string{
+	"day": "DAILY", "days": "DAILY",
+	"week": "WEEKLY", "weeks": "WEEKLY",
+	"month": "MONTHLY", "months": "MONTHLY",
+	"year": "YEARLY", "years": "YEARLY",
+}
+
+// ParseRecurrence accepts either an RRULE ("FREQ=WEEKLY;BYDAY=MO,WE", with
+// or without the "RRULE:" prefix) or a phrase such as "daily", "every
+// weekday", "every 2 weeks" or "every mon,thu".
+func ParseRecurrence(input string) (Recurrence, error) {
+	s := strings.TrimSpace(input)
+	upper := strings.ToUpper(s)
+	if strings.HasPrefix(upper, "RRULE:") || strings.Contains(upper, "FREQ=") {
+		return parseRRule(strings.TrimPrefix(upper, "RRULE:"))
+	}
+
+	s = strings.ToLower(s)
+	switch s {
+	case "daily", "every day":
+		return Recurrence{Freq: "DAILY", Interval: 1}, nil
+	case "weekdays", "every weekday":
+		return Recurrence{Freq: "WEEKLY", Interval: 1, ByDay: []time.Weekday{
+			time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+		}}, nil
+	case "weekends", "every weekend":
+		return Recurrence{Freq: "WEEKLY", Interval: 1, ByDay: []time.Weekday{time.Saturday, time.Sunday}}, nil
+	case "weekly", "every week":
+		return Recurrence{Freq: "WEEKLY", Interval: 1}, nil
+	case "monthly", "every month":
+		return Recurrence{Freq: "MONTHLY", Interval: 1}, nil
+	case "yearly", "annually", "every year":
+		return Recurrence{Freq: "YEARLY", Interval: 1}, nil
+	}
+
+	rest, ok := strings.CutPrefix(s, "every ")
+	if !ok {
+		return Recurrence{}, fmt.Errorf("unrecognized recurrence %q", input)
+	}
+
+	// "every 2 weeks"
+	if fields := strings.Fields(rest); len(fields) == 2 {
+		if n, err := strconv.Atoi(fields[0]); err == nil && n > 0 {
+			if freq, ok := freqUnits[fields[1]]; ok {
+				return Recurrence{Freq: freq, Interval: n}, nil
+			}
+		}
+	}
+
+	// "every mon,thu" or "every monday and thursday"
+	rest = strings.NewReplacer(" and ", ",", ", ", ",", " ", ",").Replace(rest)
+	days := []time.Weekday{}
+	for _, name := range strings.Split(rest, ",") {
+		wd, ok := weekdays[name]
+		if !ok {
+			return Recurrence{}, fmt.Errorf("unrecognized recurrence %q", input)
+		}
+		days = append(days, wd)
+	}
+	return Recurrence{Freq: "WEEKLY", Interval: 1, ByDay: days}, nil
+}
+
+func parseRRule(s string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(strings.TrimSuffix(s, ";"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("malformed RRULE part %q", part)
+		}
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				r.Freq = value
+			default:
+				return Recurrence{}, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = errors.New("must be positive")
+			}
+		case "BYDAY":
+			for _, name := range strings.Split(value, ",") {
+				wd, ok := rruleDays[name]
+				if !ok {
+					return Recurrence{}, fmt.Errorf("unsupported BYDAY value %q", name)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTH":
+			var m int
+			m, err = strconv.Atoi(value)
+			if err == nil && (m < 1 || m > 12) {
+				err = errors.New("out of range")
+			}
+			r.ByMonth = time.Month(m)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = strconv.Atoi(value)
+			if err == nil && (r.ByMonthDay == 0 || r.ByMonthDay < -31 || r.ByMonthDay > 31) {
+				err = errors.New("out of range")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, err = parseICalTime(value)
+		case "WKST":
+			// Weeks always start on Monday, the RFC 5545 default.
+		default:
+			return Recurrence{}, fmt.Errorf("unsupported RRULE part %q", key)
+		}
+		if err != nil {
+			return Recurrence{}, fmt.Errorf("invalid %s %q: %v", key, value, err)
+		}
+	}
+	if r.Freq == "" {
+		return Recurrence{}, errors.New("RRULE is missing FREQ")
+	}
+	if len(r.ByDay) > 0 && r.Freq != "DAILY" && r.Freq != "WEEKLY" {
+		return Recurrence{}, fmt.Errorf("BYDAY is only supported with FREQ=DAILY or WEEKLY, not %s", r.Freq)
+	}
+	return r, nil
+}
+
+// parseICalTime reads the DATE and UTC DATE-TIME forms used by UNTIL.
+func parseICalTime(s string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, nil
+	}
+	return time.Parse("20060102", s)
+}
+
+// String renders the rule in RRULE form without the "RRULE:" prefix.
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		names := []string{}
+		for _, wd := range r.ByDay {
+			names = append(names, strings.ToUpper(wd.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if r.ByMonth != 0 {
+		parts = append(parts, "BYMONTH="+strconv.Itoa(int(r.ByMonth)))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Anchor pins monthly and yearly rules to the day (and month) of start, so
+// a series that begins on the 31st keeps coming back to the end of the
+// month instead of drifting after a short month.
+func (r Recurrence) Anchor(start time.Time) Recurrence {
+	switch r.Freq {
+	case "MONTHLY":
+		if r.ByMonthDay == 0 {
+			r.ByMonthDay = start.Day()
+		}
+	case "YEARLY":
+		if r.ByMonth == 0 {
+			r.ByMonth = start.Month()
+		}
+		if r.ByMonthDay == 0 {
+			r.ByMonthDay = start.Day()
+		}
+	}
+	return r
+}
+
+// Next returns the first occurrence strictly after the given one, keeping
+// its wall-clock time across DST changes. It reports false once the rule's
+// UNTIL has passed.
+func (r Recurrence) Next(after time.Time) (time.Time, bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	var next time.Time
+	switch r.Freq {
+	case "DAILY":
+		next = after.AddDate(0, 0, interval)
+		for len(r.ByDay) > 0 && !r.onDay(next.Weekday()) {
+			next = next.AddDate(0, 0, 1)
+		}
+	case "WEEKLY":
+		if len(r.ByDay) == 0 {
+			next = after.AddDate(0, 0, 7*interval)
+			break
+		}
+		// Later days in the same Monday-based week come first, otherwise
+		// jump ahead by the interval and take the earliest matching day.
+		offset := (int(after.Weekday()) + 6) % 7
+		for d := offset + 1; d < 7 && next.IsZero(); d++ {
+			if r.onDay(time.Weekday((d + 1) % 7)) {
+				next = after.AddDate(0, 0, d-offset)
+			}
+		}
+		for d := 0; d < 7 && next.IsZero(); d++ {
+			if r.onDay(time.Weekday((d + 1) % 7)) {
+				next = after.AddDate(0, 0, 7*interval-offset+d)
+			}
+		}
+	case "MONTHLY":
+		day := r.ByMonthDay
+		if day == 0 {
+			day = after.Day()
+		}
+		next = onMonthDay(after, after.Year(), after.Month(), day)
+		if !next.After(after) {
+			next = onMonthDay(after, after.Year(), after.Month()+time.Month(interval), day)
+		}
+	case "YEARLY":
+		month, day := r.ByMonth, r.ByMonthDay
+		if month == 0 {
+			month = after.Month()
+		}
+		if day == 0 {
+			day = after.Day()
+		}
+		next = onMonthDay(after, after.Year(), month, day)
+		if !next.After(after) {
+			next = onMonthDay(after, after.Year()+interval, month, day)
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if !r.Until.IsZero() && next.After(r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r Recurrence) onDay(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// onMonthDay builds a date in the given month with clock's time of day.
+// Days past the end of the month clamp to its last day; negative days count
+// back from the end.
+func onMonthDay(clock time.Time, year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, clock.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if day < 0 {
+		day = last + 1 + day
+	}
+	if day < 1 {
+		day = 1
+	}
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day,
+		clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+}
+
+// SeriesTemplate returns the index of the task holding the recurrence rule
+// for the series that the task with the given ID belongs to.
+func SeriesTemplate(tasks []Task, id int) (int, error) {
+	for _, task := range tasks {
+		if task.ID != id {
+			continue
+		}
+		templateID := task.ID
+		if task.RecurParent != 0 {
+			templateID = task.RecurParent
+		}
+		for i := range tasks {
+			if tasks[i].ID == templateID && tasks[i].Recur != "" {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("task %d is not part of a recurring series", id)
+	}
+	return -1, fmt.Errorf("task %d not found", id)
+}
+
+// spawned is how many occurrences a series has had after its first. Series
+// from before the template kept count fall back to the ones still there.
+func spawned(tasks []Task, template Task) int {
+	n := 0
+	for _, task := range tasks {
+		if task.RecurParent == template.ID {
+			n++
+		}
+	}
+	return max(n, template.RecurCount)
+}
+
+// NextOccurrence builds the task that follows t in its recurring series,
+// due one step after t's due date, and counts it on the series template in
+// tasks. It reports false when t does not recur or when its series is
+// paused or has run out, and an error when t's series can't be found.
+func NextOccurrence(tasks []Task, t Task, now time.Time) (Task, bool, error) {
+	if t.Recur == "" && t.RecurParent == 0 {
+		return Task{}, false, nil
+	}
+	idx, err := SeriesTemplate(tasks, t.ID)
+	if err != nil {
+		return Task{}, false, err
+	}
+	template := tasks[idx]
+	if template.RecurPaused {
+		return Task{}, false, nil
+	}
+	rule, err := ParseRecurrence(template.Recur)
+	if err != nil {
+		return Task{}, false, err
+	}
+
+	count := spawned(tasks, template)
+	if rule.Count > 0 && 1+count >= rule.Count {
+		return Task{}, false, nil
+	}
+
+	base := t.Due
+	if base.IsZero() {
+		base = t.CompletedAt
+	}
+	due, ok := rule.Next(base)
+	if !ok {
+		return Task{}, false, nil
+	}
+	tasks[idx].RecurCount = count + 1
+
+	// The occurrence keeps what describes the work, not its progress
+	next := CloneTasks([]Task{t})[0]
+	delete(next.Fields, icsUIDField) // a UID names one calendar item
+	return Task{
+		ID:          NextID(tasks),
+		Description: t.Description,
+		CreatedAt:   now,
+		Encrypted:   t.Encrypted,
+		Due:         due,
+		RecurParent: template.ID,
+		Tags:        next.Tags,
+		ParentID:    t.ParentID,
+		Estimate:    t.Estimate,
+		Fields:      next.Fields,
+		Priority:    t.Priority,
+	}, true, nil
+}
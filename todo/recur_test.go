@@ -0,0 +1,271 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"daily", "FREQ=DAILY"},
+		{"every weekday", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"every 2 weeks", "FREQ=WEEKLY;INTERVAL=2"},
+		{"every mon,thu", "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{"every monday and friday", "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{"monthly", "FREQ=MONTHLY"},
+		{"every 3 months", "FREQ=MONTHLY;INTERVAL=3"},
+		{"yearly", "FREQ=YEARLY"},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1", "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=5", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=5"},
+		{"freq=daily;until=20250501T000000Z", "FREQ=DAILY;UNTIL=20250501T000000Z"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			r, err := ParseRecurrence(tc.input)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) error = %v", tc.input, err)
+			}
+			if got := r.String(); got != tc.want {
+				t.Errorf("ParseRecurrence(%q) = %s, want %s", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceInvalid(t *testing.T) {
+	for _, input := range []string{"", "sometimes", "every blue moon", "FREQ=HOURLY", "FREQ=DAILY;BYSETPOS=1", "INTERVAL=2", "FREQ=MONTHLY;BYDAY=MO", "BYDAY=FR;FREQ=YEARLY"} {
+		if _, err := ParseRecurrence(input); err == nil {
+			t.Errorf("ParseRecurrence(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	at := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, ny)
+	}
+
+	testCases := []struct {
+		name  string
+		rule  string
+		after time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "daily keeps wall clock across spring forward",
+			rule:  "daily",
+			after: at(2026, time.March, 7, 9),
+			want:  []time.Time{at(2026, time.March, 8, 9), at(2026, time.March, 9, 9)},
+		},
+		{
+			name:  "daily keeps wall clock across fall back",
+			rule:  "daily",
+			after: at(2026, time.October, 31, 9),
+			want:  []time.Time{at(2026, time.November, 1, 9), at(2026, time.November, 2, 9)},
+		},
+		{
+			name:  "weekdays skip the weekend",
+			rule:  "every weekday",
+			after: at(2026, time.March, 5, 9), // Thursday
+			want:  []time.Time{at(2026, time.March, 6, 9), at(2026, time.March, 9, 9), at(2026, time.March, 10, 9)},
+		},
+		{
+			name:  "every other week on tuesday and thursday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			after: at(2026, time.March, 3, 9), // Tuesday
+			want:  []time.Time{at(2026, time.March, 5, 9), at(2026, time.March, 17, 9), at(2026, time.March, 19, 9)},
+		},
+		{
+			name:  "monthly on the 31st clamps without drifting",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			after: at(2026, time.January, 31, 9),
+			want:  []time.Time{at(2026, time.February, 28, 9), at(2026, time.March, 31, 9), at(2026, time.April, 30, 9)},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			after: at(2024, time.January, 31, 9),
+			want:  []time.Time{at(2024, time.February, 29, 9), at(2024, time.March, 31, 9)},
+		},
+		{
+			name:  "yearly on leap day",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+			after: at(2024, time.February, 29, 9),
+			want:  []time.Time{at(2025, time.February, 28, 9), at(2026, time.February, 28, 9), at(2027, time.February, 28, 9), at(2028, time.February, 29, 9)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tc.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) error = %v", tc.rule, err)
+			}
+			prev := tc.after
+			for i, want := range tc.want {
+				got, ok := r.Next(prev)
+				if !ok {
+					t.Fatalf("occurrence %d: Next(%v) reported no occurrence", i, prev)
+				}
+				if !got.Equal(want) {
+					t.Fatalf("occurrence %d: Next(%v) = %v, want %v", i, prev, got, want)
+				}
+				prev = got
+			}
+		})
+	}
+}
+
+func TestRecurrenceAnchor(t *testing.T) {
+	r, _ := ParseRecurrence("monthly")
+	start := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+	anchored := r.Anchor(start)
+	if anchored.String() != "FREQ=MONTHLY;BYMONTHDAY=31" {
+		t.Fatalf("Anchor() = %s", anchored)
+	}
+	feb, _ := anchored.Next(start)
+	mar, _ := anchored.Next(feb)
+	if mar.Day() != 31 {
+		t.Errorf("monthly series drifted to day %d after February", mar.Day())
+	}
+}
+
+func TestRecurrenceUntil(t *testing.T) {
+	r, _ := ParseRecurrence("FREQ=DAILY;UNTIL=20260305T235959Z")
+	day := time.Date(2026, time.March, 4, 9, 0, 0, 0, time.UTC)
+	next, ok := r.Next(day)
+	if !ok || next.Day() != 5 {
+		t.Fatalf("Next(%v) = %v, %v; want March 5", day, next, ok)
+	}
+	if _, ok := r.Next(next); ok {
+		t.Errorf("Next() past UNTIL should report no occurrence")
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	now := fixedClock()
+	due := time.Date(2025, time.April, 2, 18, 0, 0, 0, now.Location())
+	series := func() []Task {
+		return []Task{
+			{ID: 1, Description: "Water plants", CreatedAt: now, Due: due, Recur: "FREQ=DAILY"},
+			{ID: 2, Description: "One-off", CreatedAt: now},
+		}
+	}
+
+	t.Run("template spawns first instance", func(t *testing.T) {
+		tasks := series()
+		tasks[0].Completed = true
+		next, ok, err := NextOccurrence(tasks, tasks[0], now)
+		if err != nil || !ok {
+			t.Fatalf("NextOccurrence() = %v, %v", ok, err)
+		}
+		if next.ID != 3 || next.RecurParent != 1 || next.Recur != "" {
+			t.Errorf("unexpected instance %+v", next)
+		}
+		if !next.Due.Equal(due.AddDate(0, 0, 1)) {
+			t.Errorf("next due = %v, want %v", next.Due, due.AddDate(0, 0, 1))
+		}
+	})
+
+	t.Run("instance links back to template", func(t *testing.T) {
+		tasks := append(series(), Task{ID: 3, Description: "Water plants", Due: due.AddDate(0, 0, 1), RecurParent: 1})
+		next, ok, _ := NextOccurrence(tasks, tasks[2], now)
+		if !ok || next.RecurParent != 1 || !next.Due.Equal(due.AddDate(0, 0, 2)) {
+			t.Errorf("unexpected instance %+v, ok = %v", next, ok)
+		}
+	})
+
+	t.Run("instance keeps tags, priority and fields", func(t *testing.T) {
+		tasks := series()
+		tasks[0].Tags = []string{"home"}
+		tasks[0].Priority, tasks[0].Estimate, tasks[0].ParentID = "H", 15*time.Minute, 2
+		tasks[0].Fields = map[string]string{"room": "kitchen", icsUIDField: "abc@example.com"}
+		next, ok, _ := NextOccurrence(tasks, tasks[0], now)
+		if !ok || !reflect.DeepEqual(next.Tags, []string{"home"}) || next.Priority != "H" || next.Estimate != 15*time.Minute ||
+			next.ParentID != 2 || !reflect.DeepEqual(next.Fields, map[string]string{"room": "kitchen"}) {
+			t.Errorf("unexpected instance %+v", next)
+		}
+		next.Tags[0], next.Fields["room"] = "work", "hall"
+		if tasks[0].Tags[0] != "home" || tasks[0].Fields["room"] != "kitchen" {
+			t.Errorf("instance shares tags or fields with the template: %+v", tasks[0])
+		}
+	})
+
+	t.Run("paused series", func(t *testing.T) {
+		tasks := series()
+		tasks[0].RecurPaused = true
+		if _, ok, _ := NextOccurrence(tasks, tasks[0], now); ok {
+			t.Errorf("paused series should not spawn")
+		}
+	})
+
+	t.Run("count limits the series", func(t *testing.T) {
+		tasks := series()
+		tasks[0].Recur = "FREQ=DAILY;COUNT=2"
+		tasks = append(tasks, Task{ID: 3, Due: due.AddDate(0, 0, 1), RecurParent: 1})
+		if _, ok, _ := NextOccurrence(tasks, tasks[2], now); ok {
+			t.Errorf("series with COUNT=2 should stop after two occurrences")
+		}
+	})
+
+	t.Run("count survives deleted occurrences", func(t *testing.T) {
+		tasks := series()
+		tasks[0].Recur = "FREQ=DAILY;COUNT=3"
+		last := tasks[0]
+		for i := 0; i < 2; i++ {
+			next, ok, err := NextOccurrence(tasks, last, now)
+			if err != nil || !ok {
+				t.Fatalf("occurrence %d: %v, %v", i+2, ok, err)
+			}
+			tasks, last = append(tasks, next), next
+		}
+		tasks, _ = RemoveTask(tasks, 3, false)
+		if tasks[0].RecurCount != 2 {
+			t.Errorf("count = %d, want 2", tasks[0].RecurCount)
+		}
+		if _, ok, _ := NextOccurrence(tasks, last, now); ok {
+			t.Errorf("series with COUNT=3 spawned a fourth occurrence: %+v", tasks)
+		}
+	})
+
+	t.Run("series outlives its template", func(t *testing.T) {
+		tasks := series()
+		tasks[0].Completed, tasks[0].RecurPaused, tasks[0].RecurCount = true, false, 1
+		tasks = append(tasks,
+			Task{ID: 3, Due: due.AddDate(0, 0, 1), RecurParent: 1, Completed: true},
+			Task{ID: 4, Due: due.AddDate(0, 0, 2), RecurParent: 1})
+		tasks, _ = RemoveTask(tasks, 1, false)
+		heir := tasks[indexOf(tasks, 4)]
+		if heir.Recur != "FREQ=DAILY" || heir.RecurParent != 0 || heir.RecurCount != 2 || tasks[indexOf(tasks, 3)].RecurParent != 4 {
+			t.Fatalf("series not handed over: %+v", tasks)
+		}
+		next, ok, err := NextOccurrence(tasks, heir, now)
+		if err != nil || !ok || next.RecurParent != 4 || !next.Due.Equal(due.AddDate(0, 0, 3)) {
+			t.Errorf("next = %+v, %v, %v", next, ok, err)
+		}
+	})
+
+	t.Run("lost series", func(t *testing.T) {
+		tasks := []Task{{ID: 3, Due: due, RecurParent: 1}}
+		if _, ok, err := NextOccurrence(tasks, tasks[0], now); ok || err == nil {
+			t.Errorf("an occurrence without its template: %v, %v", ok, err)
+		}
+	})
+
+	t.Run("plain task", func(t *testing.T) {
+		tasks := series()
+		if _, ok, err := NextOccurrence(tasks, tasks[1], now); ok || err != nil {
+			t.Errorf("non-recurring task should not spawn: %v", err)
+		}
+	})
+}
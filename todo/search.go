@@ -0,0 +1,268 @@
+package todo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchIndex is an inverted index over task descriptions, notes and tags.
+// Secret tasks are left out of it; search adds them in memory only, and an
+// index holding them refuses to be saved.
+type SearchIndex struct {
+	Version int                    `json:"version"`
+	Source  string                 `json:"source"` // SHA-256 of the tasks file it was built from
+	Docs    map[int]int            `json:"docs"`   // task ID -> number of terms
+	Terms   map[string]map[int]int `json:"terms"`  // term -> task ID -> occurrences
+
+	private bool
+}
+
+const searchIndexVersion = 1
+
+// SearchResult is a task that matched a query.
+type SearchResult struct {
+	ID      int
+	Score   float64
+	Matched []string // the indexed terms that matched, for highlighting
+}
+
+// IndexFile names the search index kept alongside a tasks file.
+func IndexFile(tasksFile string) string {
+	return tasksFile + ".idx"
+}
+
+// BuildIndex indexes every task that is not secret.
+func BuildIndex(tasks []Task) *SearchIndex {
+	idx := &SearchIndex{Version: searchIndexVersion, Docs: map[int]int{}, Terms: map[string]map[int]int{}}
+	for _, task := range tasks {
+		if task.Encrypted {
+			continue
+		}
+		texts := append([]string{task.Description}, task.Tags...)
+		for _, note := range task.Notes {
+			texts = append(texts, note.Text)
+		}
+		idx.add(task.ID, texts)
+	}
+	return idx
+}
+
+// AddPrivate indexes a secret task from its decrypted texts. The index can
+// no longer be saved afterwards.
+func (idx *SearchIndex) AddPrivate(id int, texts ...string) {
+	idx.private = true
+	idx.add(id, texts)
+}
+
+func (idx *SearchIndex) add(id int, texts []string) {
+	for _, text := range texts {
+		for _, term := range SearchTerms(text) {
+			if idx.Terms[term] == nil {
+				idx.Terms[term] = map[int]int{}
+			}
+			idx.Terms[term][id]++
+			idx.Docs[id]++
+		}
+	}
+	if _, ok := idx.Docs[id]; !ok {
+		idx.Docs[id] = 0
+	}
+}
+
+// SearchTerms splits text into lower-case words of two or more letters or
+// digits.
+func SearchTerms(text string) []string {
+	terms := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) > 1 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Search ranks tasks against the query with tf-idf. Each query word also
+// matches indexed words it is a prefix of, and words within a small edit
+// distance (one typo for words of four to seven letters, two for longer
+// ones), at a lower weight than an exact match.
+func (idx *SearchIndex) Search(query string) []SearchResult {
+	scores := map[int]float64{}
+	matched := map[int]map[string]bool{}
+	n := float64(len(idx.Docs))
+
+	for _, q := range SearchTerms(query) {
+		best := map[int]float64{}
+		for term, postings := range idx.Terms {
+			weight := matchWeight(q, term)
+			if weight == 0 {
+				continue
+			}
+			idf := math.Log(1 + n/float64(len(postings)))
+			for id, tf := range postings {
+				if s := weight * float64(tf) * idf; s > best[id] {
+					best[id] = s
+				}
+				if matched[id] == nil {
+					matched[id] = map[string]bool{}
+				}
+				matched[id][term] = true
+			}
+		}
+		for id, s := range best {
+			scores[id] += s
+		}
+	}
+
+	results := []SearchResult{}
+	for id, score := range scores {
+		terms := []string{}
+		for term := range matched[id] {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+		length := math.Max(1, float64(idx.Docs[id]))
+		results = append(results, SearchResult{ID: id, Score: score / math.Sqrt(length), Matched: terms})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+// matchWeight scores how well an indexed term answers a query word: 1 for
+// the word itself, 0.7 when the word is a prefix of it, less for typos and
+// 0 for no match.
+func matchWeight(q, term string) float64 {
+	if q == term {
+		return 1
+	}
+	qr, tr := []rune(q), []rune(term)
+	if len(qr) >= 3 && strings.HasPrefix(term, q) {
+		return 0.7
+	}
+	allowed := 0
+	switch {
+	case len(qr) >= 8:
+		allowed = 2
+	case len(qr) >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return 0
+	}
+	if d := editDistance(qr, tr, allowed); d <= allowed {
+		return 0.6 / float64(d)
+	}
+	return 0
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and swaps of neighbouring letters that turn a into b, or limit+1 once it
+// is clear the distance exceeds limit.
+func editDistance(a, b []rune, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+// OpenIndex returns the index for a tasks file, rebuilding and saving it
+// when it is missing or was built from a different version of the file.
+func OpenIndex(tasksFile string, tasks []Task) (*SearchIndex, error) {
+	data, err := os.ReadFile(tasksFile)
+	if err != nil {
+		return nil, errors.New("failed to read tasks")
+	}
+	source := sourceHash(data)
+
+	if raw, err := os.ReadFile(IndexFile(tasksFile)); err == nil {
+		idx := &SearchIndex{}
+		if json.Unmarshal(raw, idx) == nil && idx.Version == searchIndexVersion && idx.Source == source {
+			if idx.Docs == nil {
+				idx.Docs = map[int]int{}
+			}
+			if idx.Terms == nil {
+				idx.Terms = map[string]map[int]int{}
+			}
+			return idx, nil
+		}
+	}
+
+	idx := BuildIndex(tasks)
+	idx.Source = source
+	if err := idx.save(IndexFile(tasksFile)); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *SearchIndex) save(filename string) error {
+	if idx.private {
+		return errors.New("refusing to save an index holding secret tasks")
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		return errors.New("failed to write search index")
+	}
+	return nil
+}
+
+// refreshIndex rebuilds the index of a tasks file that was just written,
+// if the file has one. An index that cannot be rewritten is removed so
+// the next search starts fresh rather than reading stale results.
+func refreshIndex(tasksFile string, tasks []Task, source string) {
+	filename := IndexFile(tasksFile)
+	if _, err := os.Stat(filename); err != nil {
+		return
+	}
+	idx := BuildIndex(tasks)
+	idx.Source = source
+	if idx.save(filename) != nil {
+		os.Remove(filename)
+	}
+}
+
+func sourceHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func searchTasks(t *testing.T) []Task {
+	secret, err := EncryptText("renew the passport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []Task{
+		{ID: 1, Description: "Deploy the payment service", Tags: []string{"work"}},
+		{ID: 2, Description: "Fix login page", Notes: []Note{{Text: "customer reports a login loop"}}},
+		{ID: 3, Description: "Buy milk and bread", Tags: []string{"errands"}},
+		{ID: 4, Description: secret, Encrypted: true, Tags: []string{"errands"}},
+		{ID: 5, Description: "Write deployment notes", Tags: []string{"work", "docs"}},
+	}
+}
+
+func resultIDs(results []SearchResult) []int {
+	ids := []int{}
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	idx := BuildIndex(searchTasks(t))
+
+	testCases := []struct {
+		query string
+		want  []int
+	}{
+		{"login", []int{2}},
+		{"LOOP customer", []int{2}},
+		{"errands", []int{3}},
+		{"deploy", []int{1, 5}},     // exact match ranks above the prefix match
+		{"deployment", []int{5}},    // no typo allowance reaches "deploy"
+		{"paymnet", []int{1}},       // swapped letters count as one edit
+		{"mlik", []int{3}},          // one edit is allowed from four letters
+		{"mlk", []int{}},            // but not below that
+		{"servce", []int{1}},        // one deletion
+		{"passport", []int{}},       // secret tasks are not indexed
+		{"work notes", []int{5, 1}}, // matching more words ranks higher
+		{"", []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			if got := resultIDs(idx.Search(tc.query)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Search(%q) = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatchedTerms(t *testing.T) {
+	idx := BuildIndex(searchTasks(t))
+	results := idx.Search("deploy")
+	if len(results) != 2 || !reflect.DeepEqual(results[1].Matched, []string{"deployment"}) {
+		t.Errorf("Matched = %+v", results)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"paymnet", "payment", 1},
+		{"login", "login", 0},
+		{"login", "logn", 1},
+		{"", "abc", 3},
+	}
+	for _, tc := range testCases {
+		if got := editDistance([]rune(tc.a), []rune(tc.b), 5); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+	if got := editDistance([]rune("abcdef"), []rune("uvwxyz"), 2); got != 3 {
+		t.Errorf("levenshtein should stop at limit+1, got %d", got)
+	}
+}
+
+func TestPrivateIndexIsNotSaved(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.csv")
+	tasks := searchTasks(t)
+	if err := SaveTasks(filename, tasks); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(filename, tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.AddPrivate(4, "renew the passport")
+	if got := resultIDs(idx.Search("passport")); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("private search = %v, want [4]", got)
+	}
+	if err := idx.save(IndexFile(filename)); err == nil {
+		t.Error("saving an index with secret tasks should fail")
+	}
+	data, _ := os.ReadFile(IndexFile(filename))
+	if strings.Contains(string(data), "passport") {
+		t.Error("index file contains secret text")
+	}
+}
+
+func TestIndexKeptUpToDate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.csv")
+	tasks := []Task{{ID: 1, Description: "alpha", CreatedAt: time.Now()}}
+	if err := SaveTasks(filename, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(IndexFile(filename)); err == nil {
+		t.Fatal("saving should not create an index nobody asked for")
+	}
+	if _, err := OpenIndex(filename, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving again updates the existing index
+	tasks = append(tasks, Task{ID: 2, Description: "bravo", CreatedAt: time.Now()})
+	if err := SaveTasks(filename, tasks); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(IndexFile(filename))
+	if !strings.Contains(string(raw), "bravo") {
+		t.Errorf("index was not updated on save: %s", raw)
+	}
+
+	// Editing the file behind its back makes the index stale
+	os.WriteFile(filename, []byte("3,charlie,false,2025-04-01T00:00:00Z,,false\n"), 0644)
+	loaded, err := LoadTasks(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(filename, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(idx.Search("charlie")); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("stale index was not rebuilt: %v", got)
+	}
+}
@@ -0,0 +1,157 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Context carries what sorting, scoring and filtering need beyond the task
+// itself.
+type Context struct {
+	Tasks  []Task // the whole task list, for dependencies and subtasks
+	Config Config
+	Now    time.Time
+	Reveal bool // decrypt secret tasks when matching their text
+}
+
+// SortTasks orders tasks by a comma-separated list of keys, each
+// optionally followed by :asc or :desc, e.g. "due,priority:desc". Keys are
+// id, description, status, priority, urgency, created, completed, due,
+// wait, scheduled, estimate or the name of a custom field. Priority and
+// urgency default to descending (most important first), everything else
+// to ascending. Tasks without a value for a key go after those with one,
+// whichever the direction, and ties fall back to ID order.
+func SortTasks(tasks []Task, spec string, ctx Context) error {
+	keys := []sortKey{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		name, dir, hasDir := strings.Cut(part, ":")
+		key, err := taskSortKey(name, ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !hasDir:
+		case dir == "asc":
+			key.desc = false
+		case dir == "desc":
+			key.desc = true
+		default:
+			return fmt.Errorf("sort direction for %s must be asc or desc, got %q", name, dir)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		for _, key := range keys {
+			ma, mb := key.missing(a), key.missing(b)
+			if ma != mb {
+				return mb
+			}
+			if ma {
+				continue
+			}
+			c := key.cmp(a, b)
+			if key.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return a.ID < b.ID
+	})
+	return nil
+}
+
+// sortKey compares tasks on one property in ascending order; missing
+// reports tasks that have no value for it.
+type sortKey struct {
+	cmp     func(a, b Task) int
+	missing func(t Task) bool
+	desc    bool
+}
+
+func never(Task) bool { return false }
+
+func timeKey(get func(Task) time.Time) sortKey {
+	return sortKey{
+		cmp:     func(a, b Task) int { return compareTimes(get(a), get(b)) },
+		missing: func(t Task) bool { return get(t).IsZero() },
+	}
+}
+
+func taskSortKey(key string, ctx Context) (sortKey, error) {
+	switch key {
+	case "id":
+		return sortKey{cmp: func(a, b Task) int { return a.ID - b.ID }, missing: never}, nil
+	case "description", "desc":
+		return sortKey{cmp: func(a, b Task) int {
+			return strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+		}, missing: never}, nil
+	case "status":
+		return sortKey{cmp: func(a, b Task) int { return boolRank(a.Completed) - boolRank(b.Completed) }, missing: never}, nil
+	case "priority":
+		return sortKey{
+			cmp:     func(a, b Task) int { return priorityRank(b.Priority) - priorityRank(a.Priority) },
+			missing: func(t Task) bool { return t.Priority == "" },
+			desc:    true,
+		}, nil
+	case "urgency":
+		scores := map[int]float64{}
+		for _, task := range ctx.Tasks {
+			scores[task.ID] = Urgency(task, ctx)
+		}
+		return sortKey{cmp: func(a, b Task) int { return compareFloats(scores[a.ID], scores[b.ID]) }, missing: never, desc: true}, nil
+	case "created":
+		return timeKey(func(t Task) time.Time { return t.CreatedAt }), nil
+	case "completed":
+		return timeKey(func(t Task) time.Time { return t.CompletedAt }), nil
+	case "due":
+		return timeKey(func(t Task) time.Time { return t.Due }), nil
+	case "wait":
+		return timeKey(func(t Task) time.Time { return t.Wait }), nil
+	case "scheduled":
+		return timeKey(func(t Task) time.Time { return t.Scheduled }), nil
+	case "estimate":
+		return sortKey{
+			cmp:     func(a, b Task) int { return compareFloats(float64(a.Estimate), float64(b.Estimate)) },
+			missing: func(t Task) bool { return t.Estimate == 0 },
+		}, nil
+	}
+	if def, ok := ctx.Config.Field(key); ok {
+		return sortKey{
+			cmp:     func(a, b Task) int { return def.Compare(a.Fields[def.Name], b.Fields[def.Name]) },
+			missing: func(t Task) bool { return t.Fields[def.Name] == "" },
+		}, nil
+	}
+	return sortKey{}, fmt.Errorf("unknown sort key %q", key)
+}
+
+// compareTimes orders times chronologically with zero times last.
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Equal(b):
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	case a.Before(b):
+		return -1
+	}
+	return 1
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package todo
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats summarizes how tasks move from created to completed.
+type Stats struct {
+	Open        int
+	Completed   int
+	MeanLead    time.Duration // created to completed, over completed tasks
+	MedianLead  time.Duration
+	Weeks       []WeekStats // oldest first, ending with the current week
+	Oldest      []Task      // open tasks, oldest first
+	Tags        []TagStats  // by number of tasks, then name
+	PerWeekMean float64     // completed per week over Weeks
+}
+
+// WeekStats counts the tasks created and completed in the week starting
+// at Start.
+type WeekStats struct {
+	Start     time.Time
+	Created   int
+	Completed int
+}
+
+// TagStats is the per-tag part of Stats. Untagged tasks are counted under
+// "(untagged)".
+type TagStats struct {
+	Tag        string
+	Open       int
+	Completed  int
+	MedianLead time.Duration
+}
+
+// ComputeStats works out Stats for the given number of weeks back from
+// now, listing up to oldest open tasks. Weeks start on first, in now's time
+// zone.
+func ComputeStats(tasks []Task, now time.Time, first time.Weekday, weeks, oldest int) Stats {
+	var s Stats
+	leads := []time.Duration{}
+	tagLeads := map[string][]time.Duration{}
+	tags := map[string]*TagStats{}
+	open := []Task{}
+
+	for _, task := range tasks {
+		taskTags := task.Tags
+		if len(taskTags) == 0 {
+			taskTags = []string{"(untagged)"}
+		}
+		for _, tag := range taskTags {
+			if tags[tag] == nil {
+				tags[tag] = &TagStats{Tag: tag}
+			}
+		}
+
+		if !task.Completed {
+			s.Open++
+			open = append(open, task)
+			for _, tag := range taskTags {
+				tags[tag].Open++
+			}
+			continue
+		}
+		s.Completed++
+		lead := LeadTime(task)
+		leads = append(leads, lead)
+		for _, tag := range taskTags {
+			tags[tag].Completed++
+			tagLeads[tag] = append(tagLeads[tag], lead)
+		}
+	}
+	s.MeanLead, s.MedianLead = meanMedian(leads)
+
+	if weeks > 0 {
+		start := StartOfWeekOn(now, first).AddDate(0, 0, -7*(weeks-1))
+		for i := 0; i < weeks; i++ {
+			s.Weeks = append(s.Weeks, WeekStats{Start: start.AddDate(0, 0, 7*i)})
+		}
+		week := func(t time.Time) int {
+			if t.IsZero() || t.Before(start) {
+				return -1
+			}
+			i := int(StartOfWeekOn(t.In(now.Location()), first).Sub(start).Hours()+12) / (7 * 24)
+			if i >= weeks {
+				return -1
+			}
+			return i
+		}
+		completed := 0
+		for _, task := range tasks {
+			if i := week(task.CreatedAt); i >= 0 {
+				s.Weeks[i].Created++
+			}
+			if i := week(task.CompletedAt); task.Completed && i >= 0 {
+				s.Weeks[i].Completed++
+				completed++
+			}
+		}
+		s.PerWeekMean = float64(completed) / float64(weeks)
+	}
+
+	sort.SliceStable(open, func(i, j int) bool { return open[i].CreatedAt.Before(open[j].CreatedAt) })
+	if len(open) > oldest {
+		open = open[:oldest]
+	}
+	s.Oldest = open
+
+	for tag, entry := range tags {
+		_, entry.MedianLead = meanMedian(tagLeads[tag])
+		s.Tags = append(s.Tags, *entry)
+	}
+	sort.Slice(s.Tags, func(i, j int) bool {
+		a, b := s.Tags[i], s.Tags[j]
+		if a.Open+a.Completed != b.Open+b.Completed {
+			return a.Open+a.Completed > b.Open+b.Completed
+		}
+		return a.Tag < b.Tag
+	})
+	return s
+}
+
+// LeadTime is how long a completed task took from creation to completion.
+func LeadTime(t Task) time.Duration {
+	if !t.Completed || t.CompletedAt.Before(t.CreatedAt) {
+		return 0
+	}
+	return t.CompletedAt.Sub(t.CreatedAt)
+}
+
+func meanMedian(durations []time.Duration) (mean, median time.Duration) {
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	total := time.Duration(0)
+	for _, d := range sorted {
+		total += d
+	}
+	mean = total / time.Duration(len(sorted))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return mean, sorted[mid]
+	}
+	return mean, (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -0,0 +1,78 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := fixedClock() // Wednesday
+	day := 24 * time.Hour
+	tasks := []Task{
+		{ID: 1, CreatedAt: now.Add(-20 * day), Completed: true, CompletedAt: now.Add(-18 * day), Tags: []string{"work"}},
+		{ID: 2, CreatedAt: now.Add(-10 * day), Completed: true, CompletedAt: now.Add(-9 * day), Tags: []string{"work", "home"}},
+		{ID: 3, CreatedAt: now.Add(-2 * day), Completed: true, CompletedAt: now.Add(-1 * day)},
+		{ID: 4, CreatedAt: now.Add(-30 * day), Tags: []string{"home"}},
+		{ID: 5, CreatedAt: now.Add(-time.Hour)},
+		{ID: 6, CreatedAt: now.Add(-40 * day)},
+	}
+
+	s := ComputeStats(tasks, now, time.Monday, 3, 2)
+	if s.Open != 3 || s.Completed != 3 {
+		t.Errorf("open/completed = %d/%d, want 3/3", s.Open, s.Completed)
+	}
+	// Lead times are 2d, 1d and 1d
+	if s.MeanLead != 32*time.Hour || s.MedianLead != day {
+		t.Errorf("lead time mean/median = %v/%v, want 32h/24h", s.MeanLead, s.MedianLead)
+	}
+
+	// Weeks start on Monday Mar 17, Mar 24 and Mar 31; task 2 was added on
+	// Sunday Mar 23 and completed on Monday
+	want := []WeekStats{{Created: 1, Completed: 0}, {Created: 0, Completed: 1}, {Created: 2, Completed: 1}}
+	if len(s.Weeks) != 3 {
+		t.Fatalf("got %d weeks, want 3", len(s.Weeks))
+	}
+	for i, week := range s.Weeks {
+		if week.Created != want[i].Created || week.Completed != want[i].Completed {
+			t.Errorf("week of %s = %d created, %d completed; want %d, %d",
+				week.Start.Format("Jan 2"), week.Created, week.Completed, want[i].Created, want[i].Completed)
+		}
+	}
+	if s.Weeks[0].Start.Format("2006-01-02") != "2025-03-17" {
+		t.Errorf("first week starts %s, want 2025-03-17", s.Weeks[0].Start)
+	}
+	if s.PerWeekMean != 2.0/3 {
+		t.Errorf("completed per week = %v, want 2/3", s.PerWeekMean)
+	}
+
+	// Starting weeks on Sunday moves task 2 wholly into the second week
+	sunday := ComputeStats(tasks, now, time.Sunday, 3, 0)
+	wantSunday := []WeekStats{{Created: 0, Completed: 0}, {Created: 1, Completed: 1}, {Created: 2, Completed: 1}}
+	for i, week := range sunday.Weeks {
+		if week.Created != wantSunday[i].Created || week.Completed != wantSunday[i].Completed {
+			t.Errorf("Sunday week of %s = %d created, %d completed; want %d, %d",
+				week.Start.Format("Jan 2"), week.Created, week.Completed, wantSunday[i].Created, wantSunday[i].Completed)
+		}
+	}
+	if sunday.Weeks[0].Start.Format("2006-01-02") != "2025-03-16" {
+		t.Errorf("first Sunday week starts %s, want 2025-03-16", sunday.Weeks[0].Start)
+	}
+
+	if len(s.Oldest) != 2 || s.Oldest[0].ID != 6 || s.Oldest[1].ID != 4 {
+		t.Errorf("oldest = %+v, want tasks 6 and 4", s.Oldest)
+	}
+
+	wantTags := []TagStats{
+		{Tag: "(untagged)", Open: 2, Completed: 1, MedianLead: day},
+		{Tag: "home", Open: 1, Completed: 1, MedianLead: day},
+		{Tag: "work", Open: 0, Completed: 2, MedianLead: 36 * time.Hour},
+	}
+	if len(s.Tags) != len(wantTags) {
+		t.Fatalf("tags = %+v", s.Tags)
+	}
+	for i := range wantTags {
+		if s.Tags[i] != wantTags[i] {
+			t.Errorf("tag %d = %+v, want %+v", i, s.Tags[i], wantTags[i])
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package todo
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`^@?[\pL\pN_.-]+$`)
+
+// NormalizeTag lower-cases a tag and strips a leading "+". It reports false
+// when what is left is not a valid tag name.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "+"))
+	return tag, tagPattern.MatchString(tag)
+}
+
+// ExtractTags splits "+tag" words out of a description, returning the
+// remaining text and the tags in the order they appeared. Args are split
+// into words first, so a quoted description yields its tags too.
+func ExtractTags(args []string) (string, []string) {
+	kept := []string{}
+	tags := []string{}
+	for _, arg := range args {
+		for _, word := range strings.Fields(arg) {
+			if strings.HasPrefix(word, "+") {
+				if tag, ok := NormalizeTag(word); ok {
+					tags = AddTags(tags, tag)
+					continue
+				}
+			}
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " "), tags
+}
+
+// AddTags appends the tags not already present.
+func AddTags(tags []string, add ...string) []string {
+	for _, tag := range add {
+		if !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// RemoveTags returns tags without any of the given ones.
+func RemoveTags(tags []string, remove ...string) []string {
+	kept := []string{}
+	for _, tag := range tags {
+		if !contains(remove, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// HasTag reports whether the task carries the tag.
+func (t Task) HasTag(tag string) bool {
+	return contains(t.Tags, tag)
+}
+
+// MatchTags reports whether the task has every tag in include and none of
+// the tags in exclude.
+func MatchTags(t Task, include, exclude []string) bool {
+	for _, tag := range include {
+		if !t.HasTag(tag) {
+			return false
+		}
+	}
+	for _, tag := range exclude {
+		if t.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// TagCount is how often a tag is used.
+type TagCount struct {
+	Tag     string
+	Pending int
+	Total   int
+}
+
+// CountTags tallies tag usage, sorted by tag name.
+func CountTags(tasks []Task) []TagCount {
+	counts := map[string]*TagCount{}
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			c, ok := counts[tag]
+			if !ok {
+				c = &TagCount{Tag: tag}
+				counts[tag] = c
+			}
+			c.Total++
+			if !task.Completed {
+				c.Pending++
+			}
+		}
+	}
+
+	result := []TagCount{}
+	for _, c := range counts {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag < result[j].Tag })
+	return result
+}
+
+// RenameTag replaces each of the from tags with to on every task, merging
+// them when a task already carries to. It returns how many tasks changed.
+func RenameTag(tasks []Task, to string, from ...string) int {
+	changed := 0
+	for i := range tasks {
+		hit := false
+		for _, tag := range from {
+			if tag != to && tasks[i].HasTag(tag) {
+				hit = true
+			}
+		}
+		if !hit {
+			continue
+		}
+		renamed := []string{}
+		for _, tag := range tasks[i].Tags {
+			if contains(from, tag) {
+				tag = to
+			}
+			renamed = AddTags(renamed, tag)
+		}
+		tasks[i].Tags = renamed
+		changed++
+	}
+	return changed
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	description, tags := ExtractTags([]string{"Fix", "bug", "+Work", "+urgent", "+work", "C++", "+"})
+	if description != "Fix bug C++ +" {
+		t.Errorf("description = %q, want %q", description, "Fix bug C++ +")
+	}
+	if !reflect.DeepEqual(tags, []string{"work", "urgent"}) {
+		t.Errorf("tags = %v, want [work urgent]", tags)
+	}
+
+	// add "Fix bug +work +urgent" passes the description as one arg
+	description, tags = ExtractTags([]string{"Fix bug +work +urgent"})
+	if description != "Fix bug" || !reflect.DeepEqual(tags, []string{"work", "urgent"}) {
+		t.Errorf("quoted: description = %q, tags = %v", description, tags)
+	}
+}
+
+func TestMatchTags(t *testing.T) {
+	task := Task{Tags: []string{"work", "urgent"}}
+	testCases := []struct {
+		include, exclude []string
+		want             bool
+	}{
+		{nil, nil, true},
+		{[]string{"work"}, nil, true},
+		{[]string{"work", "home"}, nil, false},
+		{[]string{"work"}, []string{"home"}, true},
+		{nil, []string{"urgent"}, false},
+	}
+	for _, tc := range testCases {
+		if got := MatchTags(task, tc.include, tc.exclude); got != tc.want {
+			t.Errorf("MatchTags(+%v -%v) = %v, want %v", tc.include, tc.exclude, got, tc.want)
+		}
+	}
+}
+
+func TestCountTags(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Tags: []string{"work"}},
+		{ID: 2, Tags: []string{"work", "home"}, Completed: true},
+		{ID: 3},
+	}
+	want := []TagCount{{Tag: "home", Pending: 0, Total: 1}, {Tag: "work", Pending: 1, Total: 2}}
+	if got := CountTags(tasks); !reflect.DeepEqual(got, want) {
+		t.Errorf("CountTags() = %v, want %v", got, want)
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Tags: []string{"job", "urgent"}},
+		{ID: 2, Tags: []string{"work", "job", "office"}},
+		{ID: 3, Tags: []string{"home"}},
+	}
+
+	if changed := RenameTag(tasks, "work", "job", "office"); changed != 2 {
+		t.Errorf("RenameTag() changed %d tasks, want 2", changed)
+	}
+	if !reflect.DeepEqual(tasks[0].Tags, []string{"work", "urgent"}) {
+		t.Errorf("task 1 tags = %v", tasks[0].Tags)
+	}
+	if !reflect.DeepEqual(tasks[1].Tags, []string{"work"}) {
+		t.Errorf("task 2 tags = %v, want merged [work]", tasks[1].Tags)
+	}
+	if !reflect.DeepEqual(tasks[2].Tags, []string{"home"}) {
+		t.Errorf("task 3 should be untouched, got %v", tasks[2].Tags)
+	}
+}
@@ -0,0 +1,172 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Interval is a stretch of time worked on a task. A zero End means the
+// timer is still running.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ActiveTimer returns the index of the task whose timer is running, or -1.
+func ActiveTimer(tasks []Task) int {
+	for i, task := range tasks {
+		if n := len(task.TimeLog); n > 0 && task.TimeLog[n-1].End.IsZero() {
+			return i
+		}
+	}
+	return -1
+}
+
+// StartTimer starts timing the task with the given ID. Only one timer runs
+// at a time, so any other running timer is stopped first; its task ID is
+// returned, or 0 if none was running.
+func StartTimer(tasks []Task, id int, now time.Time) (int, error) {
+	idx := indexOf(tasks, id)
+	if idx < 0 {
+		return 0, fmt.Errorf("task %d not found", id)
+	}
+	if tasks[idx].Completed {
+		return 0, fmt.Errorf("task %d is already completed", id)
+	}
+
+	stopped := 0
+	if active := ActiveTimer(tasks); active == idx {
+		return 0, fmt.Errorf("timer for task %d is already running", id)
+	} else if active >= 0 {
+		stopped, _, _ = StopTimer(tasks, now)
+	}
+	tasks[idx].TimeLog = append(tasks[idx].TimeLog, Interval{Start: now})
+	return stopped, nil
+}
+
+// StopTimer stops the running timer, returning the task ID and how long
+// the interval lasted. It reports false when no timer was running.
+func StopTimer(tasks []Task, now time.Time) (int, time.Duration, bool) {
+	idx := ActiveTimer(tasks)
+	if idx < 0 {
+		return 0, 0, false
+	}
+	log := tasks[idx].TimeLog
+	log[len(log)-1].End = now
+	return tasks[idx].ID, now.Sub(log[len(log)-1].Start), true
+}
+
+// TimeSpent sums the task's intervals that fall between from and to,
+// clipping those that straddle either edge. Zero bounds are open-ended and
+// a running timer counts up to now.
+func TimeSpent(t Task, from, to, now time.Time) time.Duration {
+	total := time.Duration(0)
+	for _, interval := range t.TimeLog {
+		start, end := interval.Start, interval.End
+		if end.IsZero() {
+			end = now
+		}
+		if !from.IsZero() && start.Before(from) {
+			start = from
+		}
+		if !to.IsZero() && end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// TimeEntry is one line of a time report.
+type TimeEntry struct {
+	Name     string
+	TaskID   int
+	Spent    time.Duration
+	Estimate time.Duration
+}
+
+// TimeReport aggregates time spent between from and to per task and per
+// tag. Tasks without time in the window are left out; untagged time is
+// reported under "(untagged)". Both lists are sorted by time spent.
+func TimeReport(tasks []Task, from, to, now time.Time) (byTask, byTag []TimeEntry) {
+	tags := map[string]*TimeEntry{}
+	for _, task := range tasks {
+		spent := TimeSpent(task, from, to, now)
+		if spent == 0 {
+			continue
+		}
+		byTask = append(byTask, TimeEntry{Name: task.Description, TaskID: task.ID, Spent: spent, Estimate: task.Estimate})
+
+		taskTags := task.Tags
+		if len(taskTags) == 0 {
+			taskTags = []string{"(untagged)"}
+		}
+		for _, tag := range taskTags {
+			entry, ok := tags[tag]
+			if !ok {
+				entry = &TimeEntry{Name: tag}
+				tags[tag] = entry
+			}
+			entry.Spent += spent
+			entry.Estimate += task.Estimate
+		}
+	}
+	for _, entry := range tags {
+		byTag = append(byTag, *entry)
+	}
+
+	bySpent := func(entries []TimeEntry) {
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Spent != entries[j].Spent {
+				return entries[i].Spent > entries[j].Spent
+			}
+			return entries[i].Name < entries[j].Name
+		})
+	}
+	bySpent(byTask)
+	bySpent(byTag)
+	return byTask, byTag
+}
+
+// StartOfWeek returns midnight of the Monday starting t's week.
+func StartOfWeek(t time.Time) time.Time {
+	return StartOfWeekOn(t, time.Monday)
+}
+
+// StartOfWeekOn returns midnight of the day starting t's week when weeks
+// start on first.
+func StartOfWeekOn(t time.Time, first time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(first) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// formatTimeLog stores intervals as space-separated "start/end" pairs with
+// an empty end for a running timer.
+func formatTimeLog(log []Interval) string {
+	parts := []string{}
+	for _, interval := range log {
+		parts = append(parts, interval.Start.Format(time.RFC3339)+"/"+formatOptionalTime(interval.End))
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseTimeLog(s string) ([]Interval, error) {
+	log := []Interval{}
+	for _, part := range strings.Fields(s) {
+		startText, endText, _ := strings.Cut(part, "/")
+		start, err := time.Parse(time.RFC3339, startText)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseOptionalTime(endText)
+		if err != nil {
+			return nil, err
+		}
+		log = append(log, Interval{Start: start, End: end})
+	}
+	return log, nil
+}
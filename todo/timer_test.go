@@ -0,0 +1,104 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartStopTimer(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{{ID: 1}, {ID: 2}, {ID: 3, Completed: true}}
+
+	if stopped, err := StartTimer(tasks, 1, now); err != nil || stopped != 0 {
+		t.Fatalf("StartTimer(1) = %d, %v", stopped, err)
+	}
+	if ActiveTimer(tasks) != 0 {
+		t.Fatalf("ActiveTimer() = %d, want 0", ActiveTimer(tasks))
+	}
+	if _, err := StartTimer(tasks, 1, now); err == nil {
+		t.Errorf("starting a running timer again should fail")
+	}
+	if _, err := StartTimer(tasks, 3, now); err == nil {
+		t.Errorf("starting a timer on a completed task should fail")
+	}
+
+	// Starting task 2 stops task 1 so only one timer runs
+	stopped, err := StartTimer(tasks, 2, now.Add(30*time.Minute))
+	if err != nil || stopped != 1 {
+		t.Fatalf("StartTimer(2) = %d, %v; want task 1 stopped", stopped, err)
+	}
+	if ActiveTimer(tasks) != 1 {
+		t.Errorf("ActiveTimer() = %d, want 1", ActiveTimer(tasks))
+	}
+
+	id, elapsed, ok := StopTimer(tasks, now.Add(45*time.Minute))
+	if !ok || id != 2 || elapsed != 15*time.Minute {
+		t.Errorf("StopTimer() = %d, %v, %v", id, elapsed, ok)
+	}
+	if _, _, ok := StopTimer(tasks, now); ok {
+		t.Errorf("StopTimer() with nothing running should report false")
+	}
+	if got := TimeSpent(tasks[0], time.Time{}, time.Time{}, now); got != 30*time.Minute {
+		t.Errorf("TimeSpent(task 1) = %v, want 30m", got)
+	}
+}
+
+func TestTimeSpentClipsToWindow(t *testing.T) {
+	start := fixedClock()
+	task := Task{TimeLog: []Interval{
+		{Start: start.Add(-2 * time.Hour), End: start.Add(-time.Hour)},
+		{Start: start.Add(-30 * time.Minute), End: start.Add(30 * time.Minute)},
+		{Start: start.Add(time.Hour)}, // still running
+	}}
+	now := start.Add(90 * time.Minute)
+
+	if got := TimeSpent(task, start, time.Time{}, now); got != time.Hour {
+		t.Errorf("TimeSpent(from start) = %v, want 1h", got)
+	}
+	if got := TimeSpent(task, time.Time{}, time.Time{}, now); got != 2*time.Hour+30*time.Minute {
+		t.Errorf("TimeSpent(all) = %v, want 2h30m", got)
+	}
+}
+
+func TestTimeReport(t *testing.T) {
+	now := fixedClock()
+	hour := func(n int) Interval {
+		return Interval{Start: now.Add(time.Duration(-n) * time.Hour), End: now}
+	}
+	tasks := []Task{
+		{ID: 1, Description: "Code", Tags: []string{"work"}, Estimate: time.Hour, TimeLog: []Interval{hour(2)}},
+		{ID: 2, Description: "Review", Tags: []string{"work", "team"}, TimeLog: []Interval{hour(1)}},
+		{ID: 3, Description: "Read"},
+		{ID: 4, Description: "Garden", TimeLog: []Interval{hour(3)}},
+	}
+
+	byTask, byTag := TimeReport(tasks, time.Time{}, time.Time{}, now)
+	if len(byTask) != 3 || byTask[0].TaskID != 4 || byTask[1].TaskID != 1 || byTask[2].TaskID != 2 {
+		t.Fatalf("byTask = %+v", byTask)
+	}
+	if byTask[1].Estimate != time.Hour {
+		t.Errorf("task 1 estimate = %v, want 1h", byTask[1].Estimate)
+	}
+
+	want := map[string]time.Duration{"work": 3 * time.Hour, "team": time.Hour, "(untagged)": 3 * time.Hour}
+	if len(byTag) != len(want) {
+		t.Fatalf("byTag = %+v", byTag)
+	}
+	for _, entry := range byTag {
+		if entry.Spent != want[entry.Name] {
+			t.Errorf("tag %s spent %v, want %v", entry.Name, entry.Spent, want[entry.Name])
+		}
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	// fixedClock is a Wednesday
+	want := time.Date(2025, time.March, 31, 0, 0, 0, 0, fixedClock().Location())
+	if got := StartOfWeek(fixedClock()); !got.Equal(want) {
+		t.Errorf("StartOfWeek() = %v, want %v", got, want)
+	}
+	sunday := time.Date(2025, time.April, 6, 12, 0, 0, 0, time.UTC)
+	if got := StartOfWeek(sunday); got.Day() != 31 {
+		t.Errorf("StartOfWeek(sunday) = %v, want March 31", got)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/csv"
+	"encoding/hex"
 	"errors"
 	"io"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +23,21 @@ type Task struct {
 	CreatedAt   time.Time
 	CompletedAt time.Time
 	Encrypted   bool
+	Due         time.Time
+	Recur       string // RRULE of a recurring series, kept on the series template
+	RecurParent int    // ID of the template this occurrence was spawned from
+	RecurPaused bool
+	RecurCount  int // occurrences spawned after the first, kept on the template
+	Tags        []string
+	ParentID    int
+	DependsOn   []int
+	Notes       []Note
+	Estimate    time.Duration
+	TimeLog     []Interval
+	Fields      map[string]string // user-defined fields declared in the config
+	Wait        time.Time         // hidden from the default list until then
+	Scheduled   time.Time         // not ready to start before then
+	Priority    string            // H, M, L or empty
 }
 
 // EncryptText encrypts plaintext string with AES-GCM and returns base64 encoded result
@@ -87,25 +104,19 @@ func SaveTasks(filename string, tasks []Task) error {
 	}
 	defer file.Close()
 
-	write := csv.NewWriter(file)
-	defer write.Flush()
-
+	hash := sha256.New()
+	write := csv.NewWriter(io.MultiWriter(file, hash))
 	for _, task := range tasks {
-		record := []string{
-			strconv.Itoa(task.ID),
-			task.Description,
-			strconv.FormatBool(task.Completed),
-			task.CreatedAt.Format(time.RFC3339),
-			task.CompletedAt.Format(time.RFC3339),
-			strconv.FormatBool(task.Encrypted),
-		}
-		if !task.CompletedAt.IsZero() {
-			record[4] = task.CompletedAt.Format(time.RFC3339)
-		}
-		if err := write.Write(record); err != nil {
+		if err := write.Write(taskRecord(task)); err != nil {
 			return errors.New("failed to write record")
 		}
 	}
+	write.Flush()
+	if err := write.Error(); err != nil {
+		return errors.New("failed to write record")
+	}
+
+	refreshIndex(filename, tasks, hex.EncodeToString(hash.Sum(nil)))
 	return nil
 }
 
@@ -117,45 +128,211 @@ func LoadTasks(filename string) ([]Task, error) {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// Files written by older versions have fewer columns
+	reader.FieldsPerRecord = -1
 	records, err := reader.ReadAll() //just because it's a small file
 	if err != nil {
 		return nil, errors.New("failed to read records")
 	}
 	tasks := []Task{}
 	for _, record := range records {
-		id, err := strconv.Atoi(record[0])
+		task, err := parseRecord(record)
 		if err != nil {
-			return nil, errors.New("failed to convert ID to int")
+			return nil, err
 		}
-		completed, err := strconv.ParseBool(record[2])
-		if err != nil {
-			return nil, errors.New("failed to convert completed to bool")
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// NextID returns an ID that no task in the list uses yet.
+func NextID(tasks []Task) int {
+	max := 0
+	for _, task := range tasks {
+		if task.ID > max {
+			max = task.ID
 		}
-		createdAt, err := time.Parse(time.RFC3339, record[3])
+	}
+	return max + 1
+}
+
+// taskRecord lays a task out as a CSV row. New columns are only ever
+// appended so files written by older versions keep loading.
+func taskRecord(task Task) []string {
+	return []string{
+		strconv.Itoa(task.ID),
+		task.Description,
+		strconv.FormatBool(task.Completed),
+		task.CreatedAt.Format(time.RFC3339),
+		task.CompletedAt.Format(time.RFC3339),
+		strconv.FormatBool(task.Encrypted),
+		formatOptionalTime(task.Due),
+		task.Recur,
+		formatOptionalInt(task.RecurParent),
+		formatOptionalBool(task.RecurPaused),
+		strings.Join(task.Tags, " "),
+		formatOptionalInt(task.ParentID),
+		formatInts(task.DependsOn),
+		formatNotes(task.Notes),
+		formatOptionalDuration(task.Estimate),
+		formatTimeLog(task.TimeLog),
+		formatFields(task.Fields),
+		formatOptionalTime(task.Wait),
+		formatOptionalTime(task.Scheduled),
+		task.Priority,
+		formatOptionalInt(task.RecurCount),
+	}
+}
+
+// parseRecord is the inverse of taskRecord. Missing trailing columns leave
+// the corresponding fields at their zero value.
+func parseRecord(record []string) (Task, error) {
+	if len(record) < 5 {
+		return Task{}, errors.New("record has too few columns")
+	}
+	id, err := strconv.Atoi(record[0])
+	if err != nil {
+		return Task{}, errors.New("failed to convert ID to int")
+	}
+	completed, err := strconv.ParseBool(record[2])
+	if err != nil {
+		return Task{}, errors.New("failed to convert completed to bool")
+	}
+	createdAt, err := time.Parse(time.RFC3339, record[3])
+	if err != nil {
+		return Task{}, errors.New("failed to parse createdAt")
+	}
+	completedAt := time.Time{}
+	if record[4] != "" {
+		completedAt, err = time.Parse(time.RFC3339, record[4])
 		if err != nil {
-			return nil, errors.New("failed to parse createdAt")
-		}
-		completedAt := time.Time{}
-		if record[4] != "" {
-			completedAt, err = time.Parse(time.RFC3339, record[4])
-			if err != nil {
-				return nil, errors.New("failed to parse completedAt")
-			}
+			return Task{}, errors.New("failed to parse completedAt")
 		}
+	}
+
+	task := Task{
+		ID:          id,
+		Description: record[1],
+		Completed:   completed,
+		CreatedAt:   createdAt,
+		CompletedAt: completedAt,
+	}
+	task.Encrypted, _ = strconv.ParseBool(column(record, 5))
+	if task.Due, err = parseOptionalTime(column(record, 6)); err != nil {
+		return Task{}, errors.New("failed to parse due")
+	}
+	task.Recur = column(record, 7)
+	if task.RecurParent, err = parseOptionalInt(column(record, 8)); err != nil {
+		return Task{}, errors.New("failed to parse recurrence parent")
+	}
+	task.RecurPaused, _ = strconv.ParseBool(column(record, 9))
+	task.Tags = strings.Fields(column(record, 10))
+	if task.ParentID, err = parseOptionalInt(column(record, 11)); err != nil {
+		return Task{}, errors.New("failed to parse parent ID")
+	}
+	if task.DependsOn, err = parseInts(column(record, 12)); err != nil {
+		return Task{}, errors.New("failed to parse dependencies")
+	}
+	if task.Notes, err = parseNotes(column(record, 13)); err != nil {
+		return Task{}, errors.New("failed to parse notes")
+	}
+	if task.Estimate, err = parseOptionalDuration(column(record, 14)); err != nil {
+		return Task{}, errors.New("failed to parse estimate")
+	}
+	if task.TimeLog, err = parseTimeLog(column(record, 15)); err != nil {
+		return Task{}, errors.New("failed to parse time log")
+	}
+	if task.Fields, err = parseFields(column(record, 16)); err != nil {
+		return Task{}, errors.New("failed to parse custom fields")
+	}
+	if task.Wait, err = parseOptionalTime(column(record, 17)); err != nil {
+		return Task{}, errors.New("failed to parse wait")
+	}
+	if task.Scheduled, err = parseOptionalTime(column(record, 18)); err != nil {
+		return Task{}, errors.New("failed to parse scheduled")
+	}
+	task.Priority = column(record, 19)
+	if task.RecurCount, err = parseOptionalInt(column(record, 20)); err != nil {
+		return Task{}, errors.New("failed to parse recurrence count")
+	}
+	return task, nil
+}
+
+// column returns record[i], or "" when the row predates that column.
+func column(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+// formatOptionalTime writes zero times as an empty cell.
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseOptionalTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func formatOptionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
 
-		encrypted := false
-		if len(record) > 5 {
-			encrypted, _ = strconv.ParseBool(record[5])
+func parseOptionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func formatOptionalDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// formatInts writes a list of IDs as space-separated numbers.
+func formatInts(ids []int) string {
+	parts := []string{}
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseInts(s string) ([]int, error) {
+	ids := []int{}
+	for _, field := range strings.Fields(s) {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
 		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
 
-		tasks = append(tasks, Task{
-			ID:          id,
-			Description: record[1],
-			Completed:   completed,
-			CreatedAt:   createdAt,
-			CompletedAt: completedAt,
-			Encrypted:   encrypted,
-		})
+func formatOptionalBool(b bool) string {
+	if !b {
+		return ""
 	}
-	return tasks, nil
+	return "true"
 }
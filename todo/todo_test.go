@@ -2,6 +2,7 @@ package todo
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -27,6 +28,17 @@ func TestSaveAndLoadTasks(t *testing.T) {
 			Completed:   false,
 			CreatedAt:   createdTime,
 			CompletedAt: time.Time{},
+			Due:         createdTime.Add(48 * time.Hour),
+			Tags:        []string{"work", "urgent"},
+			Priority:    "H",
+			Wait:        createdTime.Add(24 * time.Hour),
+			Scheduled:   createdTime.Add(36 * time.Hour),
+			Estimate:    90 * time.Minute,
+			Fields:      map[string]string{"customer": "Acme & Sons", "points": "3"},
+			TimeLog: []Interval{
+				{Start: createdTime, End: createdTime.Add(30 * time.Minute)},
+				{Start: createdTime.Add(time.Hour)},
+			},
 		},
 		{
 			ID:          2,
@@ -34,6 +46,12 @@ func TestSaveAndLoadTasks(t *testing.T) {
 			Completed:   true,
 			CreatedAt:   createdTime,
 			CompletedAt: completedTime,
+			ParentID:    1,
+			DependsOn:   []int{1},
+			Notes: []Note{
+				{At: createdTime, Text: "first note, with a comma"},
+				{At: completedTime, Text: `and "quotes"`},
+			},
 		},
 	}
 
@@ -70,6 +88,36 @@ func TestSaveAndLoadTasks(t *testing.T) {
 		if original.Completed && !original.CompletedAt.Equal(loaded.CompletedAt) {
 			t.Errorf("Task %d: Expected CompletedAt %v, got %v", i, original.CompletedAt, loaded.CompletedAt)
 		}
+		if !original.Due.Equal(loaded.Due) {
+			t.Errorf("Task %d: Expected Due %v, got %v", i, original.Due, loaded.Due)
+		}
+		if strings.Join(original.Tags, " ") != strings.Join(loaded.Tags, " ") {
+			t.Errorf("Task %d: Expected Tags %v, got %v", i, original.Tags, loaded.Tags)
+		}
+		if formatInts(original.DependsOn) != formatInts(loaded.DependsOn) {
+			t.Errorf("Task %d: Expected DependsOn %v, got %v", i, original.DependsOn, loaded.DependsOn)
+		}
+		if formatNotes(original.Notes) != formatNotes(loaded.Notes) {
+			t.Errorf("Task %d: Expected Notes %v, got %v", i, original.Notes, loaded.Notes)
+		}
+		if formatFields(original.Fields) != formatFields(loaded.Fields) {
+			t.Errorf("Task %d: Expected Fields %v, got %v", i, original.Fields, loaded.Fields)
+		}
+		if !original.Wait.Equal(loaded.Wait) || !original.Scheduled.Equal(loaded.Scheduled) {
+			t.Errorf("Task %d: Expected Wait/Scheduled %v/%v, got %v/%v", i, original.Wait, original.Scheduled, loaded.Wait, loaded.Scheduled)
+		}
+		if original.Priority != loaded.Priority {
+			t.Errorf("Task %d: Expected Priority %q, got %q", i, original.Priority, loaded.Priority)
+		}
+		if original.Estimate != loaded.Estimate {
+			t.Errorf("Task %d: Expected Estimate %v, got %v", i, original.Estimate, loaded.Estimate)
+		}
+		if formatTimeLog(original.TimeLog) != formatTimeLog(loaded.TimeLog) {
+			t.Errorf("Task %d: Expected TimeLog %v, got %v", i, original.TimeLog, loaded.TimeLog)
+		}
+		if original.ParentID != loaded.ParentID {
+			t.Errorf("Task %d: Expected ParentID %d, got %d", i, original.ParentID, loaded.ParentID)
+		}
 	}
 }
 
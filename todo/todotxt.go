@@ -0,0 +1,334 @@
+package todo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// todo.txt priorities run from (A) to (Z). The first three map onto H, M
+// and L; the rest are kept in the "pri" field so they survive a round trip.
+var todoTxtPriorities = map[string]string{"A": "H", "B": "M", "C": "L"}
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtKeyValue = regexp.MustCompile(`^([\pL][^\s:/]*):([^\s/]\S*)$`)
+)
+
+// FormatTodoTxt writes a task as a todo.txt line:
+//
+//	x 2025-04-02 (B) 2025-03-30 Pay rent +home @phone due:2025-04-05 points:3
+//
+// Tags starting with "@" are contexts, the others projects. Due, wait (t:),
+// scheduled, estimate, recurrence (rrule:) and each note become key:value
+// pairs, as do custom fields. Description words that would read back as a
+// tag or a pair are escaped. Secret tasks must be decrypted first.
+func FormatTodoTxt(t Task) string {
+	words := []string{}
+	pri := todoTxtLetter(t)
+	if t.Completed {
+		words = append(words, "x", t.CompletedAt.Format("2006-01-02"))
+	} else if pri != "" {
+		words = append(words, "("+pri+")")
+	}
+	words = append(words, t.CreatedAt.Format("2006-01-02"))
+	for _, word := range strings.Fields(t.Description) {
+		words = append(words, escapeTodoTxtWord(word))
+	}
+	for _, tag := range t.Tags {
+		if strings.HasPrefix(tag, "@") {
+			words = append(words, tag)
+		} else {
+			words = append(words, "+"+tag)
+		}
+	}
+
+	pair := func(key, value string) {
+		if strings.ContainsAny(value, " \t") {
+			value = url.PathEscape(value)
+		}
+		words = append(words, key+":"+value)
+	}
+	if !t.Due.IsZero() {
+		pair("due", todoTxtDate(t.Due))
+	}
+	if !t.Wait.IsZero() {
+		pair("t", todoTxtDate(t.Wait))
+	}
+	if !t.Scheduled.IsZero() {
+		pair("scheduled", todoTxtDate(t.Scheduled))
+	}
+	if t.Estimate != 0 {
+		pair("estimate", formatEstimate(t.Estimate))
+	}
+	if t.Recur != "" {
+		pair("rrule", t.Recur)
+	}
+	for _, note := range t.Notes {
+		words = append(words, "note:"+note.At.Format(todoTxtNoteTime)+","+url.PathEscape(note.Text))
+	}
+	keys := []string{}
+	for key := range t.Fields {
+		if key != "pri" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pair(key, t.Fields[key])
+	}
+	if t.Completed && pri != "" {
+		pair("pri", pri)
+	}
+	return strings.Join(words, " ")
+}
+
+const todoTxtNoteTime = "2006-01-02T15:04:05"
+
+// TodoTxtDropped names what of a task a todo.txt line has no place for:
+// its links to other tasks, whose IDs an import does not keep, its time
+// log and whether its series is paused.
+func TodoTxtDropped(t Task) []string {
+	dropped := []string{}
+	if t.ParentID != 0 {
+		dropped = append(dropped, "parent")
+	}
+	if len(t.DependsOn) > 0 {
+		dropped = append(dropped, "dependencies")
+	}
+	if len(t.TimeLog) > 0 {
+		dropped = append(dropped, "time log")
+	}
+	if t.RecurPaused {
+		dropped = append(dropped, "paused recurrence")
+	}
+	return dropped
+}
+
+// todoTxtSpecial reports whether a word reads as a tag or a key:value pair.
+func todoTxtSpecial(word string) bool {
+	if strings.HasPrefix(word, "+") || strings.HasPrefix(word, "@") {
+		if _, ok := NormalizeTag(word); ok {
+			return true
+		}
+	}
+	return todoTxtKeyValue.MatchString(word)
+}
+
+// escapeTodoTxtWord keeps a description word from reading back as a tag or
+// a pair by percent-encoding its leading "+" or "@", or its first colon.
+func escapeTodoTxtWord(word string) string {
+	switch {
+	case !todoTxtSpecial(word):
+		return word
+	case strings.HasPrefix(word, "+"):
+		return "%2B" + word[1:]
+	case strings.HasPrefix(word, "@"):
+		return "%40" + word[1:]
+	}
+	return strings.Replace(word, ":", "%3A", 1)
+}
+
+// unescapeTodoTxtWord undoes escapeTodoTxtWord, leaving any other word with
+// a "%" in it alone.
+func unescapeTodoTxtWord(word string) string {
+	if unescaped, err := url.PathUnescape(word); err == nil && unescaped != word && todoTxtSpecial(unescaped) {
+		return unescaped
+	}
+	return word
+}
+
+// todoTxtLetter is the todo.txt priority letter of a task, if any.
+func todoTxtLetter(t Task) string {
+	for letter, priority := range todoTxtPriorities {
+		if t.Priority == priority {
+			return letter
+		}
+	}
+	return t.Fields["pri"]
+}
+
+// todoTxtDate writes whole days as a date and anything else with the time.
+func todoTxtDate(t time.Time) string {
+	if t.Equal(EndOfDay(t)) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04")
+}
+
+func parseTodoTxtDate(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return EndOfDay(t), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04", s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, got %q", s)
+	}
+	return t, nil
+}
+
+// ParseTodoTxt reads one todo.txt line into a task without an ID. Dates
+// without one default to now. Keys it does not know are kept as fields,
+// and values of fields declared in the config are checked against them.
+func ParseTodoTxt(line string, cfg Config, clock Clock) (Task, error) {
+	now := clock()
+	words := strings.Fields(line)
+	task := Task{CreatedAt: now}
+	date := func() (time.Time, bool) {
+		if len(words) == 0 {
+			return time.Time{}, false
+		}
+		t, err := time.ParseInLocation("2006-01-02", words[0], now.Location())
+		if err != nil {
+			return time.Time{}, false
+		}
+		words = words[1:]
+		return t, true
+	}
+	setPriority := func(letter string) {
+		if priority, ok := todoTxtPriorities[letter]; ok {
+			task.Priority = priority
+			return
+		}
+		if task.Fields == nil {
+			task.Fields = map[string]string{}
+		}
+		task.Fields["pri"] = letter
+	}
+
+	if len(words) > 0 && words[0] == "x" {
+		words = words[1:]
+		task.Completed = true
+		task.CompletedAt = now
+		if t, ok := date(); ok {
+			task.CompletedAt = t
+		}
+	}
+	if len(words) > 0 {
+		if m := todoTxtPriority.FindStringSubmatch(words[0]); m != nil {
+			setPriority(m[1])
+			words = words[1:]
+		}
+	}
+	if t, ok := date(); ok {
+		task.CreatedAt = t
+	}
+
+	text := []string{}
+	for _, word := range words {
+		if strings.HasPrefix(word, "+") || strings.HasPrefix(word, "@") {
+			if tag, ok := NormalizeTag(word); ok {
+				task.Tags = AddTags(task.Tags, tag)
+				continue
+			}
+		}
+		m := todoTxtKeyValue.FindStringSubmatch(word)
+		if m == nil {
+			text = append(text, unescapeTodoTxtWord(word))
+			continue
+		}
+		key, value := m[1], m[2]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		if err := setTodoTxtValue(&task, cfg, key, value, clock, setPriority); err != nil {
+			return Task{}, err
+		}
+	}
+
+	task.Description = strings.Join(text, " ")
+	if task.Description == "" {
+		return Task{}, errors.New("task has no description")
+	}
+	return task, nil
+}
+
+func setTodoTxtValue(task *Task, cfg Config, key, value string, clock Clock, setPriority func(string)) error {
+	loc := clock().Location()
+	var err error
+	switch key {
+	case "due":
+		task.Due, err = parseTodoTxtDate(value, loc)
+	case "t":
+		task.Wait, err = parseTodoTxtDate(value, loc)
+	case "scheduled":
+		task.Scheduled, err = parseTodoTxtDate(value, loc)
+	case "estimate":
+		task.Estimate, err = time.ParseDuration(value)
+	case "rrule":
+		var rule Recurrence
+		if rule, err = ParseRecurrence(value); err == nil {
+			task.Recur = rule.String()
+		}
+	case "note":
+		at, text, ok := strings.Cut(value, ",")
+		if !ok {
+			return fmt.Errorf("note: expected TIME,text, got %q", value)
+		}
+		t, err := time.ParseInLocation(todoTxtNoteTime, at, loc)
+		if err != nil {
+			return fmt.Errorf("note: expected %s, got %q", todoTxtNoteTime, at)
+		}
+		task.Notes = append(task.Notes, Note{At: t, Text: text})
+	case "pri":
+		if !todoTxtPriority.MatchString("(" + value + ")") {
+			return fmt.Errorf("pri: expected a letter A-Z, got %q", value)
+		}
+		setPriority(value)
+	default:
+		if def, ok := cfg.Field(key); ok {
+			value, err = def.Parse(value, clock)
+		}
+		if task.Fields == nil {
+			task.Fields = map[string]string{}
+		}
+		task.Fields[key] = value
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	return nil
+}
+
+// WriteTodoTxt writes tasks one per line.
+func WriteTodoTxt(w io.Writer, tasks []Task) error {
+	for _, task := range tasks {
+		if _, err := fmt.Fprintln(w, FormatTodoTxt(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadTodoTxt reads a todo.txt file, skipping blank lines. Errors name the
+// line they are on; all of them are reported at once.
+func ReadTodoTxt(r io.Reader, cfg Config, clock Clock) ([]Task, error) {
+	tasks := []Task{}
+	errs := []error{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		task, err := ParseTodoTxt(line, cfg, clock)
+		if err != nil {
+			errs = append(errs, &EditError{Line: n, Msg: err.Error()})
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTodoTxt(t *testing.T) {
+	loc := fixedClock().Location()
+	task, err := ParseTodoTxt("x 2025-04-01 2025-03-28 Call mom +Family @phone due:2025-04-05 points:3.0 rec:+1w pri:B", testConfig(), fixedClock)
+	if err != nil {
+		t.Fatalf("ParseTodoTxt: %v", err)
+	}
+	if !task.Completed || !task.CompletedAt.Equal(time.Date(2025, time.April, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("completed = %v at %v", task.Completed, task.CompletedAt)
+	}
+	if !task.CreatedAt.Equal(time.Date(2025, time.March, 28, 0, 0, 0, 0, loc)) {
+		t.Errorf("created = %v", task.CreatedAt)
+	}
+	if task.Description != "Call mom" || strings.Join(task.Tags, " ") != "family @phone" {
+		t.Errorf("description %q, tags %v", task.Description, task.Tags)
+	}
+	if !task.Due.Equal(time.Date(2025, time.April, 5, 23, 59, 59, 0, loc)) {
+		t.Errorf("due = %v", task.Due)
+	}
+	if task.Priority != "M" || task.Fields["points"] != "3" || task.Fields["rec"] != "+1w" {
+		t.Errorf("priority %q, fields %v", task.Priority, task.Fields)
+	}
+
+	task, err = ParseTodoTxt("(D) Read http://example.com at 12:30", testConfig(), fixedClock)
+	if err != nil {
+		t.Fatalf("ParseTodoTxt: %v", err)
+	}
+	if task.Description != "Read http://example.com at 12:30" || task.Priority != "" || task.Fields["pri"] != "D" || !task.CreatedAt.Equal(fixedClock()) {
+		t.Errorf("got %+v", task)
+	}
+
+	// Rules are stored the way the recur command writes them
+	task, err = ParseTodoTxt("Stand-up rrule:byday=mo,we;freq=weekly;interval=1", testConfig(), fixedClock)
+	if err != nil || task.Recur != "FREQ=WEEKLY;BYDAY=MO,WE" {
+		t.Errorf("recur = %q, %v", task.Recur, err)
+	}
+
+	for _, line := range []string{"+work @home", "Plan due:someday", "Plan size:XL", "Plan pri:9"} {
+		if _, err := ParseTodoTxt(line, testConfig(), fixedClock); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+}
+
+// Lines already in the order FormatTodoTxt writes come back unchanged.
+func TestTodoTxtRoundTrip(t *testing.T) {
+	input := strings.Join([]string{
+		"(A) 2025-03-30 Pay rent +home @phone due:2025-04-05 t:2025-04-03 estimate:30m",
+		"x 2025-04-01 2025-03-28 Call mom +family due:2025-04-01T18:00 rec:+1w pri:B",
+		"(K) 2025-03-01 Someday task scheduled:2025-05-01 rrule:FREQ=WEEKLY;BYDAY=MO",
+		"2025-03-02 Note with spaces size:M thread:mail:42 ticket:ABC%20123",
+		"x 2025-03-05 2025-03-01 Done without priority",
+		"",
+	}, "\n")
+
+	tasks, err := ReadTodoTxt(strings.NewReader(input), testConfig(), fixedClock)
+	if err != nil {
+		t.Fatalf("ReadTodoTxt: %v", err)
+	}
+	if len(tasks) != 5 {
+		t.Fatalf("read %d tasks, want 5", len(tasks))
+	}
+	if tasks[3].Fields["ticket"] != "ABC 123" || tasks[3].Fields["thread"] != "mail:42" {
+		t.Errorf("fields = %v", tasks[3].Fields)
+	}
+
+	var out bytes.Buffer
+	if err := WriteTodoTxt(&out, tasks); err != nil {
+		t.Fatalf("WriteTodoTxt: %v", err)
+	}
+	if out.String() != input {
+		t.Errorf("round trip =\n%s\nwant\n%s", out.String(), input)
+	}
+
+	again, err := ReadTodoTxt(&out, testConfig(), fixedClock)
+	if err != nil {
+		t.Fatalf("ReadTodoTxt: %v", err)
+	}
+	for i := range tasks {
+		if !sameRecords([]Task{tasks[i]}, []Task{again[i]}) {
+			t.Errorf("task %d changed:\n%+v\n%+v", i, tasks[i], again[i])
+		}
+	}
+}
+
+// Tasks written by FormatTodoTxt read back the same, even with words in
+// their description that look like tags or pairs.
+func TestTodoTxtTaskRoundTrip(t *testing.T) {
+	now := fixedClock()
+	// Creation and completion dates are days; due dates end them
+	day := func(days int) time.Time {
+		y, m, d := now.AddDate(0, 0, days).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	}
+	tasks := []Task{
+		{Description: "Review re:budget notes for +1 @ the C++ meeting", CreatedAt: day(-3), Due: EndOfDay(day(2)), Priority: "H",
+			Tags: []string{"work", "@office"}, Notes: []Note{
+				{At: now.Add(-time.Hour).Truncate(time.Second), Text: "ask Ana, then 50% of it / more"},
+				{At: now.Truncate(time.Second), Text: "two\nlines"}}},
+		{Description: "Send +money to @bob at 10:30 via http://pay.example", CreatedAt: day(-1),
+			Completed: true, CompletedAt: day(0), Fields: map[string]string{"size": "M"}},
+	}
+
+	for _, task := range tasks {
+		line := FormatTodoTxt(task)
+		got, err := ParseTodoTxt(line, testConfig(), fixedClock)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		if !sameRecords([]Task{got}, []Task{task}) {
+			t.Errorf("%s read back as\n%+v\nwant\n%+v", line, got, task)
+		}
+	}
+
+	if dropped := TodoTxtDropped(Task{ParentID: 1, DependsOn: []int{2}, RecurPaused: true}); strings.Join(dropped, ", ") != "parent, dependencies, paused recurrence" {
+		t.Errorf("dropped = %v", dropped)
+	}
+}
+
+func TestReadTodoTxtErrors(t *testing.T) {
+	_, err := ReadTodoTxt(strings.NewReader("Fine\n\n(A) due:tomorrow\nx +only\n"), testConfig(), fixedClock)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"line 3: due:", "line 4: task has no description"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+}
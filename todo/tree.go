@@ -0,0 +1,188 @@
+package todo
+
+// TreeNode is a task placed in the subtask hierarchy.
+type TreeNode struct {
+	Task  Task
+	Depth int
+}
+
+// Children returns the tasks whose parent is the task with the given ID.
+func Children(tasks []Task, id int) []Task {
+	children := []Task{}
+	for _, task := range tasks {
+		if task.ParentID == id && task.ID != id {
+			children = append(children, task)
+		}
+	}
+	return children
+}
+
+// Progress counts the completed and total direct subtasks of a task.
+func Progress(tasks []Task, id int) (done, total int) {
+	for _, child := range Children(tasks, id) {
+		total++
+		if child.Completed {
+			done++
+		}
+	}
+	return done, total
+}
+
+// OpenChildren returns the IDs of a task's subtasks that are not done yet.
+func OpenChildren(tasks []Task, id int) []int {
+	open := []int{}
+	for _, child := range Children(tasks, id) {
+		if !child.Completed {
+			open = append(open, child.ID)
+		}
+	}
+	return open
+}
+
+// CompletableParents walks up from the task with the given ID and returns
+// the open ancestors whose subtasks are now all complete, nearest first.
+func CompletableParents(tasks []Task, id int) []int {
+	byID := map[int]Task{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	parents := []int{}
+	completed := map[int]bool{}
+	seen := map[int]bool{}
+	current := byID[id]
+	for current.ParentID != 0 && !seen[current.ParentID] {
+		seen[current.ParentID] = true
+		parent, ok := byID[current.ParentID]
+		if !ok || parent.Completed {
+			break
+		}
+		for _, child := range Children(tasks, parent.ID) {
+			if !child.Completed && !completed[child.ID] && child.ID != id {
+				return parents
+			}
+		}
+		parents = append(parents, parent.ID)
+		completed[parent.ID] = true
+		current = parent
+	}
+	return parents
+}
+
+// TreeOrder arranges tasks depth-first under their parents, keeping the
+// original order among siblings. Tasks whose parent is not in the list are
+// shown at the top level.
+func TreeOrder(tasks []Task) []TreeNode {
+	present := map[int]bool{}
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	nodes := []TreeNode{}
+	visited := map[int]bool{}
+	var walk func(task Task, depth int)
+	walk = func(task Task, depth int) {
+		if visited[task.ID] {
+			return
+		}
+		visited[task.ID] = true
+		nodes = append(nodes, TreeNode{Task: task, Depth: depth})
+		for _, child := range Children(tasks, task.ID) {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, task := range tasks {
+		if task.ParentID == 0 || !present[task.ParentID] {
+			walk(task, 0)
+		}
+	}
+	// Anything left is caught in a parent cycle; show it rather than lose it
+	for _, task := range tasks {
+		walk(task, 0)
+	}
+	return nodes
+}
+
+// RemoveTask deletes the task with the given ID. With cascade its subtasks
+// are deleted too; otherwise they move up to the deleted task's parent.
+// Dependencies on removed tasks are dropped, and a recurring series whose
+// template goes passes to its newest remaining occurrence. It returns the
+// remaining tasks and the IDs that were removed.
+func RemoveTask(tasks []Task, id int, cascade bool) ([]Task, []int) {
+	doomed := map[int]bool{id: true}
+	parentOf := map[int]int{}
+	for _, task := range tasks {
+		parentOf[task.ID] = task.ParentID
+	}
+
+	if cascade {
+		for changed := true; changed; {
+			changed = false
+			for _, task := range tasks {
+				if doomed[task.ParentID] && !doomed[task.ID] {
+					doomed[task.ID] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	kept := []Task{}
+	removed := []int{}
+	for _, task := range tasks {
+		if doomed[task.ID] {
+			removed = append(removed, task.ID)
+			continue
+		}
+		if task.ParentID == id {
+			task.ParentID = parentOf[id]
+		}
+		kept = append(kept, task)
+	}
+	for i := range kept {
+		kept[i].DependsOn = withoutInts(kept[i].DependsOn, removed)
+	}
+	for _, task := range tasks {
+		if doomed[task.ID] && task.Recur != "" {
+			handOverSeries(kept, task)
+		}
+	}
+	return kept, removed
+}
+
+// handOverSeries makes the newest occurrence left in tasks the template of
+// the series template held, carrying its rule, pause and count.
+func handOverSeries(tasks []Task, template Task) {
+	heir := -1
+	for i, task := range tasks {
+		if task.RecurParent == template.ID && (heir < 0 || task.ID > tasks[heir].ID) {
+			heir = i
+		}
+	}
+	if heir < 0 {
+		return
+	}
+	count := spawned(tasks, template)
+	heirID := tasks[heir].ID
+	for i := range tasks {
+		if tasks[i].RecurParent == template.ID {
+			tasks[i].RecurParent = heirID
+		}
+	}
+	tasks[heir].RecurParent = 0
+	tasks[heir].Recur, tasks[heir].RecurPaused, tasks[heir].RecurCount = template.Recur, template.RecurPaused, count
+}
+
+func withoutInts(list, remove []int) []int {
+	if len(list) == 0 {
+		return list
+	}
+	kept := []int{}
+	for _, n := range list {
+		if !containsInt(remove, n) {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,84 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sampleTree is 1 > (2, 3 > 4), plus an unrelated task 5.
+func sampleTree() []Task {
+	return []Task{
+		{ID: 1, Description: "Release"},
+		{ID: 5, Description: "Other"},
+		{ID: 2, Description: "Build", ParentID: 1, Completed: true},
+		{ID: 3, Description: "Test", ParentID: 1},
+		{ID: 4, Description: "Unit tests", ParentID: 3},
+	}
+}
+
+func TestTreeOrder(t *testing.T) {
+	got := []int{}
+	depths := []int{}
+	for _, node := range TreeOrder(sampleTree()) {
+		got = append(got, node.Task.ID)
+		depths = append(depths, node.Depth)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("TreeOrder() IDs = %v, want [1 2 3 4 5]", got)
+	}
+	if !reflect.DeepEqual(depths, []int{0, 1, 1, 2, 0}) {
+		t.Errorf("TreeOrder() depths = %v, want [0 1 1 2 0]", depths)
+	}
+}
+
+func TestTreeOrderSurvivesCycles(t *testing.T) {
+	tasks := []Task{{ID: 1, ParentID: 2}, {ID: 2, ParentID: 1}}
+	if nodes := TreeOrder(tasks); len(nodes) != 2 {
+		t.Errorf("TreeOrder() returned %d nodes for a cycle, want 2", len(nodes))
+	}
+}
+
+func TestProgress(t *testing.T) {
+	done, total := Progress(sampleTree(), 1)
+	if done != 1 || total != 2 {
+		t.Errorf("Progress(1) = %d/%d, want 1/2", done, total)
+	}
+	if !reflect.DeepEqual(OpenChildren(sampleTree(), 1), []int{3}) {
+		t.Errorf("OpenChildren(1) = %v, want [3]", OpenChildren(sampleTree(), 1))
+	}
+}
+
+func TestCompletableParents(t *testing.T) {
+	tasks := sampleTree()
+	tasks[4].Completed = true // unit tests done, so "Test" and then "Release" can close
+	if got := CompletableParents(tasks, 4); !reflect.DeepEqual(got, []int{3, 1}) {
+		t.Errorf("CompletableParents(4) = %v, want [3 1]", got)
+	}
+
+	tasks = sampleTree()
+	tasks[2].Completed = false
+	tasks[4].Completed = true // "Build" still open, so only "Test" closes
+	if got := CompletableParents(tasks, 4); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("CompletableParents(4) = %v, want [3]", got)
+	}
+}
+
+func TestRemoveTask(t *testing.T) {
+	kept, removed := RemoveTask(sampleTree(), 3, false)
+	if !reflect.DeepEqual(removed, []int{3}) {
+		t.Errorf("removed = %v, want [3]", removed)
+	}
+	for _, task := range kept {
+		if task.ID == 4 && task.ParentID != 1 {
+			t.Errorf("task 4 should be re-parented to 1, got parent %d", task.ParentID)
+		}
+	}
+
+	kept, removed = RemoveTask(sampleTree(), 1, true)
+	if !reflect.DeepEqual(removed, []int{1, 2, 3, 4}) {
+		t.Errorf("cascade removed = %v, want [1 2 3 4]", removed)
+	}
+	if len(kept) != 1 || kept[0].ID != 5 {
+		t.Errorf("cascade kept = %v, want only task 5", kept)
+	}
+}
@@ -0,0 +1,154 @@
+package todo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// UrgencyWeights sets how much each property of a task contributes to its
+// urgency score. Every weight can be overridden under "urgency" in the
+// config file.
+type UrgencyWeights struct {
+	PriorityH float64            `json:"priority_h"`
+	PriorityM float64            `json:"priority_m"`
+	PriorityL float64            `json:"priority_l"`
+	Due       float64            `json:"due"`       // scaled by how close the due date is
+	Age       float64            `json:"age"`       // scaled by age, reaching full weight after AgeMax days
+	AgeMax    float64            `json:"age_max"`   // days
+	Tags      float64            `json:"tags"`      // scaled by number of tags
+	Blocking  float64            `json:"blocking"`  // other open tasks depend on it
+	Blocked   float64            `json:"blocked"`   // it depends on open tasks
+	Scheduled float64            `json:"scheduled"` // its scheduled date has arrived
+	Waiting   float64            `json:"waiting"`   // still hidden behind its wait date
+	Active    float64            `json:"active"`    // a timer is running on it
+	Tag       map[string]float64 `json:"tag"`       // extra weight per tag name
+}
+
+// DefaultUrgency returns the weights used when the config sets none.
+func DefaultUrgency() UrgencyWeights {
+	return UrgencyWeights{
+		PriorityH: 6.0,
+		PriorityM: 3.9,
+		PriorityL: 1.8,
+		Due:       12.0,
+		Age:       2.0,
+		AgeMax:    365,
+		Tags:      1.0,
+		Blocking:  8.0,
+		Blocked:   -5.0,
+		Scheduled: 5.0,
+		Waiting:   -3.0,
+		Active:    4.0,
+		Tag:       map[string]float64{"next": 15.0},
+	}
+}
+
+// Urgency scores how pressing an open task is; completed tasks score 0.
+// It is the sum of each weight multiplied by a factor between 0 and 1:
+//
+//   - priority: 1 for the matching priority weight
+//   - due: 0.2 when due two weeks or more from now, rising linearly to 1
+//     when a week or more overdue; 0 without a due date
+//   - age: days since creation divided by AgeMax, capped at 1
+//   - tags: 0.8 for one tag, 0.9 for two, 1 for three or more
+//   - blocking, blocked, scheduled, waiting, active: 1 when the condition holds
+//   - tag: 1 for each tag with its own weight
+func Urgency(t Task, ctx Context) float64 {
+	if t.Completed {
+		return 0
+	}
+	w := ctx.Config.Urgency
+	score := 0.0
+
+	switch t.Priority {
+	case "H":
+		score += w.PriorityH
+	case "M":
+		score += w.PriorityM
+	case "L":
+		score += w.PriorityL
+	}
+
+	if !t.Due.IsZero() {
+		score += w.Due * dueFactor(t.Due, ctx.Now)
+	}
+
+	if w.AgeMax > 0 {
+		age := ctx.Now.Sub(t.CreatedAt).Hours() / 24 / w.AgeMax
+		score += w.Age * math.Max(0, math.Min(1, age))
+	}
+
+	switch n := len(t.Tags); {
+	case n == 1:
+		score += w.Tags * 0.8
+	case n == 2:
+		score += w.Tags * 0.9
+	case n >= 3:
+		score += w.Tags
+	}
+	for _, tag := range t.Tags {
+		score += w.Tag[tag]
+	}
+
+	if Blocking(ctx.Tasks, t) {
+		score += w.Blocking
+	}
+	if Blocked(ctx.Tasks, t) {
+		score += w.Blocked
+	}
+	if !t.Scheduled.IsZero() && !t.Scheduled.After(ctx.Now) {
+		score += w.Scheduled
+	}
+	if t.Waiting(ctx.Now) {
+		score += w.Waiting
+	}
+	if n := len(t.TimeLog); n > 0 && t.TimeLog[n-1].End.IsZero() {
+		score += w.Active
+	}
+	return score
+}
+
+// dueFactor maps the distance to a due date onto 0.2..1: two weeks out or
+// more is 0.2 and a week or more overdue is 1.
+func dueFactor(due, now time.Time) float64 {
+	overdue := now.Sub(due).Hours() / 24
+	switch {
+	case overdue >= 7:
+		return 1.0
+	case overdue >= -14:
+		return (overdue+14)*0.8/21 + 0.2
+	default:
+		return 0.2
+	}
+}
+
+// ParsePriority accepts H, M or L (or high, medium, low) in any case, and
+// "" or "none" to clear the priority.
+func ParsePriority(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "h", "high":
+		return "H", nil
+	case "m", "medium", "med":
+		return "M", nil
+	case "l", "low":
+		return "L", nil
+	case "", "none":
+		return "", nil
+	}
+	return "", fmt.Errorf("priority must be H, M or L, got %q", s)
+}
+
+// priorityRank orders priorities H, M, L, then none.
+func priorityRank(p string) int {
+	switch p {
+	case "H":
+		return 0
+	case "M":
+		return 1
+	case "L":
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,118 @@
+package todo
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUrgency(t *testing.T) {
+	now := fixedClock()
+	w := UrgencyWeights{
+		PriorityH: 6, PriorityM: 3.9, PriorityL: 1.8,
+		Due: 12, Age: 2, AgeMax: 100, Tags: 1,
+		Blocking: 8, Blocked: -5, Scheduled: 5, Waiting: -3, Active: 4,
+		Tag: map[string]float64{"next": 15},
+	}
+	ctx := Context{Config: Config{Urgency: w}, Now: now}
+
+	testCases := []struct {
+		name string
+		task Task
+		want float64
+	}{
+		{"nothing set", Task{ID: 1, CreatedAt: now}, 0},
+		{"completed", Task{ID: 1, CreatedAt: now, Priority: "H", Completed: true}, 0},
+		{"high priority", Task{ID: 1, CreatedAt: now, Priority: "H"}, 6},
+		{"low priority", Task{ID: 1, CreatedAt: now, Priority: "L"}, 1.8},
+		{"due far out", Task{ID: 1, CreatedAt: now, Due: now.AddDate(0, 0, 30)}, 12 * 0.2},
+		{"due now", Task{ID: 1, CreatedAt: now, Due: now}, 12 * (14*0.8/21 + 0.2)},
+		{"week overdue", Task{ID: 1, CreatedAt: now, Due: now.AddDate(0, 0, -7)}, 12},
+		{"half aged", Task{ID: 1, CreatedAt: now.AddDate(0, 0, -50)}, 1},
+		{"fully aged", Task{ID: 1, CreatedAt: now.AddDate(-1, 0, 0)}, 2},
+		{"one tag", Task{ID: 1, CreatedAt: now, Tags: []string{"home"}}, 0.8},
+		{"two tags", Task{ID: 1, CreatedAt: now, Tags: []string{"home", "work"}}, 0.9},
+		{"weighted tag", Task{ID: 1, CreatedAt: now, Tags: []string{"next", "a", "b"}}, 16},
+		{"scheduled", Task{ID: 1, CreatedAt: now, Scheduled: now.Add(-time.Hour)}, 5},
+		{"scheduled later", Task{ID: 1, CreatedAt: now, Scheduled: now.Add(time.Hour)}, 0},
+		{"waiting", Task{ID: 1, CreatedAt: now, Wait: now.Add(time.Hour)}, -3},
+		{"active", Task{ID: 1, CreatedAt: now, TimeLog: []Interval{{Start: now}}}, 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Urgency(tc.task, ctx); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("Urgency = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUrgencyDependencies(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, CreatedAt: now},
+		{ID: 2, CreatedAt: now, DependsOn: []int{1}},
+		{ID: 3, CreatedAt: now, DependsOn: []int{4}},
+		{ID: 4, CreatedAt: now, Completed: true},
+	}
+	ctx := Context{Tasks: tasks, Config: DefaultConfig(), Now: now}
+
+	if got := Urgency(tasks[0], ctx); got != 8 {
+		t.Errorf("blocking task: Urgency = %v, want 8", got)
+	}
+	if got := Urgency(tasks[1], ctx); got != -5 {
+		t.Errorf("blocked task: Urgency = %v, want -5", got)
+	}
+	if got := Urgency(tasks[2], ctx); got != 0 {
+		t.Errorf("task with finished dependency: Urgency = %v, want 0", got)
+	}
+}
+
+func TestSortByUrgency(t *testing.T) {
+	now := fixedClock()
+	tasks := []Task{
+		{ID: 1, CreatedAt: now},
+		{ID: 2, CreatedAt: now, Priority: "L"},
+		{ID: 3, CreatedAt: now, Priority: "H"},
+		{ID: 4, CreatedAt: now, Due: now.AddDate(0, 0, -10)},
+	}
+	ctx := Context{Tasks: tasks, Config: DefaultConfig(), Now: now}
+	if err := SortTasks(tasks, "urgency", ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{4, 3, 2, 1}
+	for i, task := range tasks {
+		if task.ID != want[i] {
+			t.Fatalf("position %d: got task %d, want %d", i, task.ID, want[i])
+		}
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	testCases := map[string]string{"h": "H", "High": "H", "M": "M", "medium": "M", "low": "L", "": "", "none": ""}
+	for input, want := range testCases {
+		got, err := ParsePriority(input)
+		if err != nil || got != want {
+			t.Errorf("ParsePriority(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("ParsePriority(\"urgent\") should fail")
+	}
+}
+
+func TestLoadConfigKeepsDefaultWeights(t *testing.T) {
+	filename := t.TempDir() + "/config.json"
+	if err := os.WriteFile(filename, []byte(`{"urgency": {"priority_h": 10}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Urgency.PriorityH != 10 || cfg.Urgency.Due != 12 {
+		t.Errorf("weights = %+v, want priority_h overridden and the rest default", cfg.Urgency)
+	}
+}